@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"github.com/uhppoted/uhppoted-lib/config"
 
 	"github.com/uhppoted/uhppoted-app-db/commands"
+	"github.com/uhppoted/uhppoted-app-db/log"
 )
 
 var cli = []uhppoted.Command{
@@ -18,7 +20,17 @@ var cli = []uhppoted.Command{
 	&commands.CompareACLCmd,
 	&commands.GetACLCmd,
 	&commands.PutACLCmd,
+	&commands.LoadProfilesCmd,
 	&commands.GetEventsCmd,
+	&commands.HistoryACLCmd,
+	&commands.ServeCmd,
+	&commands.CheckPermissionsCmd,
+	&commands.CopyACLCmd,
+	&commands.CopyEventsCmd,
+	&commands.CopyAuditCmd,
+	&commands.CopyLogCmd,
+	&commands.PurgeExpiredCmd,
+	&commands.CapacityCmd,
 
 	&uhppoted.Version{
 		Application: commands.APP,
@@ -33,11 +45,21 @@ var options = commands.Options{
 	Debug:  false,
 }
 
+var logFormat = "text"
+
 func main() {
 	flag.StringVar(&options.Config, "config", options.Config, "configuration file to use for controller identification and configuration")
 	flag.BoolVar(&options.Debug, "debug", options.Debug, "Enable debugging information")
+	flag.StringVar(&logFormat, "log-format", logFormat, "Log format ('text' or 'json')")
 	flag.Parse()
 
+	if err := log.SetFormat(logFormat); err != nil {
+		fmt.Printf("\n   ERROR: %v\n\n", err)
+		os.Exit(1)
+	}
+
+	log.SetDebug(options.Debug)
+
 	cmd, err := uhppoted.Parse(cli, nil, help)
 	if err != nil {
 		fmt.Printf("\nError parsing command line: %v\n\n", err)
@@ -50,7 +72,18 @@ func main() {
 	}
 
 	if err = cmd.Execute(&options); err != nil {
+		var exit commands.ExitError
+
+		if errors.As(err, &exit) && exit.Err == nil {
+			os.Exit(exit.Code)
+		}
+
 		fmt.Printf("\n   ERROR: %v\n\n", err)
+
+		if errors.As(err, &exit) {
+			os.Exit(exit.Code)
+		}
+
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+)
+
+// cachedACL is the last successfully loaded ACL table for a DSN. The DSN is stored as a hash of the DSN
+// without the credentials (see withoutCredentials).
+type cachedACL struct {
+	DSN     string     `json:"dsn"`
+	Cached  time.Time  `json:"cached"`
+	Header  []string   `json:"header"`
+	Records [][]string `json:"records"`
+}
+
+// signedACL is the cache file format. The signature is the HMAC-SHA256 of the payload with the cache key.
+type signedACL struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signature string          `json:"signature"`
+}
+
+// aclCache is the signed local cache of the last successfully loaded ACL table used by load-acl when
+// the DB is unavailable.
+type aclCache struct {
+	file string
+	dsn  string
+	key  []byte
+}
+
+// newACLCache returns the ACL cache for the DSN in the cache directory. The HMAC key used to sign the
+// cache is read from the key file, which is expected to not be accessible by other users.
+func newACLCache(dir string, keyfile string, dsn string) (*aclCache, error) {
+	if dir == "" {
+		return nil, nil
+	} else if keyfile == "" {
+		return nil, fmt.Errorf("ACL cache requires a cache key (--cache-key)")
+	}
+
+	key, err := readSecret(keyfile)
+	if err != nil {
+		return nil, err
+	} else if len(key) < 16 {
+		return nil, fmt.Errorf("invalid cache key file %v (expected at least 16 characters)", keyfile)
+	}
+
+	hash := sha256.Sum256([]byte(withoutCredentials(dsn)))
+
+	return &aclCache{
+		file: filepath.Join(dir, fmt.Sprintf("acl-%x.json", hash[:8])),
+		dsn:  hex.EncodeToString(hash[:]),
+		key:  []byte(key),
+	}, nil
+}
+
+// save writes the ACL table to the cache file. The file is written to a temporary file and renamed so
+// that an interrupted write does not replace the last good cache.
+func (c *aclCache) save(table lib.Table) error {
+	payload, err := json.Marshal(cachedACL{
+		DSN:     c.dsn,
+		Cached:  time.Now(),
+		Header:  table.Header,
+		Records: table.Records,
+	})
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(signedACL{
+		Payload:   payload,
+		Signature: c.sign(payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.file), 0700); err != nil {
+		return err
+	}
+
+	tmp := c.file + ".tmp"
+	if err := os.WriteFile(tmp, bytes, 0600); err != nil {
+		return err
+	} else if err := os.Rename(tmp, c.file); err != nil {
+		return err
+	}
+
+	debugf("cache", "saved ACL to %v", c.file)
+
+	return nil
+}
+
+// load reads the ACL table from the cache file, returning an error if the cache signature is invalid or
+// the cache was stored for a different DSN.
+func (c *aclCache) load() (lib.Table, time.Time, error) {
+	var signed signedACL
+	var cached cachedACL
+
+	if bytes, err := os.ReadFile(c.file); err != nil {
+		return lib.Table{}, time.Time{}, err
+	} else if err := json.Unmarshal(bytes, &signed); err != nil {
+		return lib.Table{}, time.Time{}, fmt.Errorf("invalid ACL cache %v (%v)", c.file, err)
+	} else if !hmac.Equal([]byte(c.sign(signed.Payload)), []byte(signed.Signature)) {
+		return lib.Table{}, time.Time{}, fmt.Errorf("invalid ACL cache %v signature", c.file)
+	} else if err := json.Unmarshal(signed.Payload, &cached); err != nil {
+		return lib.Table{}, time.Time{}, fmt.Errorf("invalid ACL cache %v (%v)", c.file, err)
+	} else if cached.DSN != c.dsn {
+		return lib.Table{}, time.Time{}, fmt.Errorf("ACL cache %v is for a different DSN", c.file)
+	} else {
+		return lib.Table{Header: cached.Header, Records: cached.Records}, cached.Cached, nil
+	}
+}
+
+func (c *aclCache) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// unexpired removes the cards with a 'to' date before today from an ACL table.
+func unexpired(table lib.Table, today time.Time) lib.Table {
+	cardnumber := -1
+	to := -1
+
+	for i, h := range table.Header {
+		switch normalise(h) {
+		case "cardnumber":
+			cardnumber = i
+		case "to":
+			to = i
+		}
+	}
+
+	if to < 0 {
+		return table
+	}
+
+	date := today.Format("2006-01-02")
+	records := [][]string{}
+
+	for _, record := range table.Records {
+		if to < len(record) && record[to] != "" && record[to] < date {
+			if cardnumber >= 0 && cardnumber < len(record) {
+				infof("cache", "removing expired card %v (%v)", record[cardnumber], record[to])
+			}
+		} else {
+			records = append(records, record)
+		}
+	}
+
+	return lib.Table{
+		Header:  table.Header,
+		Records: records,
+	}
+}
+
+// withoutCredentials returns the DSN with the user name and password removed, so that the cache file
+// name and stored DSN hash cannot be used to recover (or brute force) the DB password and the cache is
+// unaffected by a password change:
+//
+//   - mysql:// DSNs are split at the last '@' before the database name, as for the MySQL driver
+//   - postgresql:// and sqlserver:// DSNs are URLs and the user info and any 'password' query
+//     parameter are removed
+//   - sqlite3:// DSNs do not have credentials
+func withoutCredentials(dsn string) string {
+	switch {
+	case strings.HasPrefix(dsn, "mysql://"):
+		s := strings.TrimPrefix(dsn, "mysql://")
+		if ix := strings.LastIndex(s, "/"); ix >= 0 {
+			if jx := strings.LastIndex(s[:ix], "@"); jx >= 0 {
+				return "mysql://" + s[jx+1:]
+			}
+		}
+
+	case strings.HasPrefix(dsn, "postgresql://"), strings.HasPrefix(dsn, "sqlserver://"):
+		if u, err := url.Parse(dsn); err == nil {
+			query := u.Query()
+			for k := range query {
+				if strings.EqualFold(k, "password") {
+					query.Del(k)
+				}
+			}
+
+			u.User = nil
+			u.RawQuery = query.Encode()
+
+			return u.String()
+		}
+	}
+
+	return dsn
+}
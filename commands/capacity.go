@@ -0,0 +1,284 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/uhppoted/uhppote-core/uhppote"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var CapacityCmd = Capacity{
+	command: command{
+		name:        "capacity",
+		description: "Reports the number of cards each controller will hold after loading the ACL from the database",
+		usage:       "[--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:groups <table> --table:members <table>] [--capacity <cards>] [--warn <percent>]",
+
+		dsn: "",
+		tables: tables{
+			ACL: "ACL",
+		},
+		lockfile: "",
+		config:   config.DefaultConfig,
+		debug:    false,
+	},
+	capacity: 0,
+	warn:     90,
+}
+
+type Capacity struct {
+	command
+	capacity uint
+	warn     uint
+}
+
+// capacities holds the configured controller card capacities, keyed by controller ID (or 'default')
+// e.g.
+//
+//	db.capacity.default = 20000
+//	db.capacity.405419896 = 60000
+type capacities map[string]string
+
+func (m *capacities) UnmarshalConf(tag string, values map[string]string) (any, error) {
+	v, err := (&doormap{}).UnmarshalConf(tag, values)
+	if err != nil {
+		return nil, err
+	}
+
+	c := capacities(*v.(*doormap))
+
+	return &c, nil
+}
+
+func (cmd *Capacity) Help() {
+	fmt.Println()
+	fmt.Printf("  Usage: %s [--debug] [--config <file>] capacity [--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:groups <table> --table:members <table>] [--capacity <cards>] [--warn <percent>]\n", APP)
+	fmt.Println()
+	fmt.Println("  Reports the number of cards each controller will hold after loading the ACL from the database, along with")
+	fmt.Println("  the number of cards currently stored on the controller and the controller card capacity. Warns if a load")
+	fmt.Println("  would exceed the warning threshold and fails if a load would exceed the controller capacity.")
+	fmt.Println()
+
+	helpOptions(cmd.FlagSet())
+
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println(`    uhppote-app-db capacity --dsn "sqlite3://./db/ACL.db"`)
+	fmt.Println(`    uhppote-app-db capacity --dsn "sqlite3://./db/ACL.db" --capacity 20000 --warn 80`)
+	fmt.Println()
+}
+
+func (cmd *Capacity) FlagSet() *flag.FlagSet {
+	flagset := flag.NewFlagSet("capacity", flag.ExitOnError)
+
+	flagset.StringVar(&cmd.dsn, "dsn", cmd.dsn, "DSN for database")
+	flagset.StringVar(&cmd.tables.ACL, "table:ACL", cmd.tables.ACL, "ACL table name. Defaults to ACL")
+	flagset.StringVar(&cmd.tables.Groups, "table:groups", cmd.tables.Groups, "Optional access groups table name. Defaults to ''")
+	flagset.StringVar(&cmd.tables.Members, "table:members", cmd.tables.Members, "Optional access group members table name. Defaults to ''")
+	flagset.StringVar(&cmd.columns, "columns", cmd.columns, "Optional file with the ACL table column mapping. Defaults to the mapping in the configuration file")
+	flagset.UintVar(&cmd.capacity, "capacity", cmd.capacity, "Controller card capacity. Defaults to the db.capacity settings in the configuration file")
+	flagset.UintVar(&cmd.warn, "warn", cmd.warn, "Warning threshold as a percentage of the controller card capacity")
+	flagset.StringVar(&cmd.lockfile, "lockfile", cmd.lockfile, "Filepath for lock file. Defaults to <tmp>/uhppoted-app-db.lock")
+	flagset.StringVar(&cmd.profile, "profile", cmd.profile, "Optional named profile from the configuration file with the DSN, tables and options")
+
+	cmd.flagset = flagset
+
+	return flagset
+}
+
+func (cmd *Capacity) Execute(args ...any) error {
+	options := args[0].(*Options)
+
+	cmd.config = options.Config
+	cmd.debug = options.Debug
+
+	// ... check parameters
+	if err := cmd.resolve(); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(cmd.tables.ACL) == "" {
+		return fmt.Errorf("invalid ACL table")
+	}
+
+	// ... locked?
+	if kraken, err := lock(cmd.lockfile); err != nil {
+		return err
+	} else {
+		defer func() {
+			cmd.infof("capacity", "removing lockfile")
+			kraken.Release()
+		}()
+	}
+
+	// ... get config
+	conf := config.NewConfig()
+	if err := conf.Load(cmd.config); err != nil {
+		return fmt.Errorf("could not load configuration (%v)", err)
+	}
+
+	u, devices := getDevices(conf, cmd.timeout, cmd.debug)
+
+	limits, err := getCapacities(cmd.config, devices)
+	if err != nil {
+		return err
+	}
+
+	if cmd.capacity > 0 {
+		for _, device := range devices {
+			limits[device.DeviceID] = int(cmd.capacity)
+		}
+	}
+
+	// ... get ACL table column mapping
+	columns, err := cmd.getColumns()
+	if err != nil {
+		return err
+	}
+
+	// ... build ACL from DB
+	acl, err := func() (*lib.ACL, error) {
+		if table, err := getACL(cmd.dsn, cmd.tls, cmd.tables.ACL, columns, false); err != nil {
+			return nil, err
+		} else if table, err := expand(cmd.dsn, cmd.tls, cmd.tables, columns, table); err != nil {
+			return nil, err
+		} else if table, _, err := activeCards(table); err != nil {
+			return nil, err
+		} else if table, scoped, err := scopes(table); err != nil {
+			return nil, err
+		} else if acl, _, err := lib.ParseTable(&table, devices, false); err != nil {
+			return nil, err
+		} else if acl == nil {
+			return nil, fmt.Errorf("error creating ACL from DB table (%v)", acl)
+		} else {
+			restrict(*acl, scoped)
+			return acl, nil
+		}
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	// ... report
+	over := []string{}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(w, "CONTROLLER\tNAME\tCURRENT\tPLANNED\tCAPACITY\tUSAGE\tSTATUS\t")
+
+	for _, device := range devices {
+		controller := device.DeviceID
+		planned := len((*acl)[controller])
+		current := cmd.current(u, controller)
+		capacity, usage, status := "-", "-", "-"
+
+		if limit, ok := limits[controller]; ok && limit > 0 {
+			capacity = fmt.Sprintf("%v", limit)
+			usage = fmt.Sprintf("%v%%", 100*planned/limit)
+			status = "ok"
+
+			if planned > limit {
+				status = "over capacity"
+				over = append(over, fmt.Sprintf("%v", controller))
+				cmd.warnf("capacity", "%v  %v cards exceeds capacity of %v", controller, planned, limit)
+			} else if 100*planned >= int(cmd.warn)*limit {
+				status = "warning"
+				cmd.warnf("capacity", "%v  %v cards is %v of capacity (%v)", controller, planned, usage, limit)
+			}
+		}
+
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t\n", controller, device.Name, current, planned, capacity, usage, status)
+	}
+
+	w.Flush()
+
+	if len(over) > 0 {
+		return fmt.Errorf("ACL exceeds the card capacity of controllers %v", strings.Join(over, ", "))
+	}
+
+	return nil
+}
+
+// current returns the number of cards currently stored on the controller, or '-' if the controller
+// could not be reached.
+func (cmd *Capacity) current(u uhppote.IUHPPOTE, controller uint32) string {
+	if N, err := u.GetCards(controller); err != nil {
+		cmd.warnf("capacity", "%v  error retrieving card count (%v)", controller, err)
+		return "-"
+	} else {
+		return fmt.Sprintf("%v", N)
+	}
+}
+
+// getCapacities returns the configured card capacity for each controller, using the 'default' capacity
+// for controllers without an explicit capacity.
+func getCapacities(file string, devices []uhppote.Device) (map[uint32]int, error) {
+	s, err := loadSettings(file)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := map[uint32]int{}
+	parse := func(k, v string) (int, error) {
+		if N, err := strconv.Atoi(v); err != nil || N <= 0 {
+			return 0, fmt.Errorf("invalid db.capacity.%v (%v)", k, v)
+		} else {
+			return N, nil
+		}
+	}
+
+	if v, ok := s.Capacity["default"]; ok {
+		if N, err := parse("default", v); err != nil {
+			return nil, err
+		} else {
+			for _, device := range devices {
+				limits[device.DeviceID] = N
+			}
+		}
+	}
+
+	for k, v := range s.Capacity {
+		if k == "default" {
+			continue
+		}
+
+		if controller, err := strconv.ParseUint(k, 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid db.capacity controller (%v)", k)
+		} else if N, err := parse(k, v); err != nil {
+			return nil, err
+		} else {
+			limits[uint32(controller)] = N
+		}
+	}
+
+	return limits, nil
+}
+
+// checkCapacity warns if the ACL exceeds the configured card capacity of any of the controllers, returning
+// the list of controllers that would be over capacity.
+func (cmd command) checkCapacity(acl lib.ACL, devices []uhppote.Device) []error {
+	limits, err := getCapacities(cmd.config, devices)
+	if err != nil {
+		cmd.warnf("capacity", "%v", err)
+		return []error{err}
+	}
+
+	errors := []error{}
+	for _, controller := range slices.Sorted(maps.Keys(acl)) {
+		if limit, ok := limits[controller]; ok && len(acl[controller]) > limit {
+			err := fmt.Errorf("%v  ACL has %v cards, exceeding the controller capacity of %v", controller, len(acl[controller]), limit)
+
+			cmd.warnf("capacity", "%v", err)
+			errors = append(errors, err)
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppote-core/uhppote"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+)
+
+func TestGetCapacities(t *testing.T) {
+	devices := []uhppote.Device{
+		{DeviceID: 405419896},
+		{DeviceID: 303986753},
+	}
+
+	tests := []struct {
+		name     string
+		settings string
+		expected map[uint32]int
+		err      bool
+	}{
+		{"default and controller", "db.capacity.default = 20000\ndb.capacity.405419896 = 60000\n", map[uint32]int{405419896: 60000, 303986753: 20000}, false},
+		{"invalid default", "db.capacity.default = lots\n", nil, true},
+		{"zero capacity", "db.capacity.405419896 = 0\n", nil, true},
+	}
+
+	for _, test := range tests {
+		conf := filepath.Join(t.TempDir(), "uhppoted.conf")
+		if err := os.WriteFile(conf, []byte(test.settings), 0600); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		limits, err := getCapacities(conf, devices)
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected error, got %v", test.name, limits)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%v: unexpected error (%v)", test.name, err)
+		}
+
+		if !reflect.DeepEqual(limits, test.expected) {
+			t.Errorf("%v: incorrect capacities - expected:%v, got:%v", test.name, test.expected, limits)
+		}
+	}
+}
+
+func TestCheckCapacity(t *testing.T) {
+	devices := []uhppote.Device{
+		{DeviceID: 405419896},
+		{DeviceID: 303986753},
+	}
+
+	cards := func(N int) map[uint32]core.Card {
+		m := map[uint32]core.Card{}
+		for i := range N {
+			m[uint32(10058400+i)] = core.Card{CardNumber: uint32(10058400 + i)}
+		}
+
+		return m
+	}
+
+	acl := lib.ACL{
+		405419896: cards(3),
+		303986753: cards(2),
+	}
+
+	tests := []struct {
+		name     string
+		settings string
+		expected []string
+	}{
+		{"within capacity", "db.capacity.default = 3\n", []string{}},
+		{"over capacity", "db.capacity.default = 2\n", []string{"405419896  ACL has 3 cards, exceeding the controller capacity of 2"}},
+		{"invalid capacity", "db.capacity.default = lots\n", []string{"invalid db.capacity.default (lots)"}},
+	}
+
+	for _, test := range tests {
+		conf := filepath.Join(t.TempDir(), "uhppoted.conf")
+		if err := os.WriteFile(conf, []byte(test.settings), 0600); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		cmd := command{config: conf}
+		errors := []string{}
+		for _, err := range cmd.checkCapacity(acl, devices) {
+			errors = append(errors, err.Error())
+		}
+
+		if !reflect.DeepEqual(errors, test.expected) {
+			t.Errorf("%v: incorrect capacity errors\n   expected:%v\n   got:     %v", test.name, strings.Join(test.expected, "; "), strings.Join(errors, "; "))
+		}
+	}
+}
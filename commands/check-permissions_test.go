@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestCheckPermissionsRequirements(t *testing.T) {
+	columns := db.DefaultColumns
+
+	tests := []struct {
+		command  string
+		tables   tables
+		mark     bool
+		remove   bool
+		expected []requirement
+	}{
+		{
+			command: "purge-expired",
+			tables:  tables{Audit: "Audit", Log: "OpsLog"},
+			expected: []requirement{
+				{"ACL", "ACL", "CardNumber", []db.Operation{db.Select}},
+				{"audit", "Audit", "Timestamp", []db.Operation{db.Select, db.Insert}},
+				{"log", "OpsLog", "Timestamp", []db.Operation{db.Select, db.Insert}},
+			},
+		},
+		{
+			command: "purge-expired",
+			tables:  tables{Audit: "Audit", Log: "OpsLog"},
+			mark:    true,
+			remove:  true,
+			expected: []requirement{
+				{"ACL", "ACL", "CardNumber", []db.Operation{db.Select}},
+				{"ACL", "ACL", "Status", []db.Operation{db.Update}},
+				{"ACL", "ACL", "CardNumber", []db.Operation{db.Delete}},
+				{"audit", "Audit", "Timestamp", []db.Operation{db.Select, db.Insert}},
+				{"log", "OpsLog", "Timestamp", []db.Operation{db.Select, db.Insert}},
+			},
+		},
+		{
+			command: "copy-acl",
+			expected: []requirement{
+				{"ACL", "ACL", "CardNumber", []db.Operation{db.Select, db.Upsert, db.Delete}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		defaults, ok := defaultTables[test.command]
+		if !ok {
+			t.Fatalf("%v: missing default tables", test.command)
+		}
+
+		cmd := CheckPermissions{
+			command: command{tables: test.tables},
+			target:  test.command,
+			mark:    test.mark,
+			remove:  test.remove,
+		}
+
+		if requirements := cmd.requirements(defaults, columns); !reflect.DeepEqual(requirements, test.expected) {
+			t.Errorf("%v: incorrect requirements\n   expected:%v\n   got:     %v", test.command, test.expected, requirements)
+		}
+	}
+}
+
+func TestCheckPermissionsWhileLocked(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "events.db")
+	conf := filepath.Join(dir, "uhppoted.conf")
+
+	t.Setenv("TMPDIR", dir)
+
+	if err := os.WriteFile(conf, []byte{}, 0600); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+		t.Fatalf("%v", err)
+	} else if _, err := dbc.Exec(`CREATE TABLE Events (Controller INTEGER, EventIndex INTEGER);`); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		dbc.Close()
+	}
+
+	// ... hold the default lockfile as e.g. a running load-acl would
+	kraken, err := lock("")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	defer kraken.Release()
+
+	cmd := CheckPermissionsCmd
+	cmd.dsn = "sqlite3://" + dsn
+	cmd.target = "get-events"
+
+	if err := cmd.Execute(&Options{Config: conf}); err != nil {
+		t.Errorf("unexpected error checking permissions while locked (%v)", err)
+	}
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/uhppoted/uhppoted-lib/lockfile"
 	lib "github.com/uhppoted/uhppoted-lib/os"
 
+	"github.com/uhppoted/uhppoted-app-db/db"
 	"github.com/uhppoted/uhppoted-app-db/log"
+	"github.com/uhppoted/uhppoted-app-db/metrics"
 )
 
 const APP = "uhppoted-app-db"
@@ -22,6 +24,33 @@ type Options struct {
 	Debug  bool
 }
 
+// Exit codes returned by commands (currently only compare-acl) that report an outcome in the process
+// exit status, following the Nagios plugin convention.
+const (
+	ExitOK          = 0
+	ExitDifferences = 1
+	ExitFailed      = 2
+)
+
+// ExitError is returned by a command to set the process exit code. A nil Err indicates that the
+// command completed successfully but with a non-zero exit code e.g. compare-acl found differences.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e ExitError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("exit code %v", e.Code)
+}
+
+func (e ExitError) Unwrap() error {
+	return e.Err
+}
+
 type command struct {
 	name        string
 	description string
@@ -30,16 +59,30 @@ type command struct {
 	dsn      string
 	tables   tables
 	withPIN  bool
+	pinKey   string
+	columns  string
 	lockfile string
 	config   string
 	debug    bool
+	started  time.Time
+	profile  string
+	timeout  time.Duration
+	tls      db.TLS
+	runID    string
+	resolved bool
+	flagset  *flag.FlagSet
 }
 
 type tables struct {
-	ACL    string
-	Audit  string
-	Events string
-	Log    string
+	ACL      string
+	Audit    string
+	Events   string
+	Log      string
+	Groups   string
+	Members  string
+	Profiles string
+	Holidays string
+	History  string
 }
 
 func (cmd command) Name() string {
@@ -67,10 +110,15 @@ func lock(file string) (lockfile.Lockfile, error) {
 		}
 	}
 
-	return lockfile.MakeLockFile(lockFile)
+	kraken, err := lockfile.MakeLockFile(lockFile)
+	if err != nil {
+		metrics.Add(metrics.LockContention, nil, 1)
+	}
+
+	return kraken, err
 }
 
-func getDevices(conf *config.Config, debug bool) (uhppote.IUHPPOTE, []uhppote.Device) {
+func getDevices(conf *config.Config, timeout time.Duration, debug bool) (uhppote.IUHPPOTE, []uhppote.Device) {
 	bind, broadcast, listen := config.DefaultIpAddresses()
 
 	if conf.BindAddress != nil {
@@ -87,7 +135,11 @@ func getDevices(conf *config.Config, debug bool) (uhppote.IUHPPOTE, []uhppote.De
 
 	controllers := conf.Devices.ToControllers()
 
-	u := uhppote.NewUHPPOTE(bind, broadcast, listen, 5*time.Second, controllers, debug)
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	u := uhppote.NewUHPPOTE(bind, broadcast, listen, timeout, controllers, debug)
 
 	return u, controllers
 }
@@ -158,3 +210,29 @@ func errorf(tag, format string, args ...any) {
 
 	log.Errorf(f, args...)
 }
+
+// The command debugf, infof, warnf and errorf log with the run ID of the command invocation (e.g. a run
+// triggered by the 'serve' command), defaulting to the process run ID.
+func (cmd command) debugf(tag string, format string, args ...any) {
+	f := fmt.Sprintf("%-10v %v", tag, format)
+
+	log.Run(cmd.runID).Debugf(f, args...)
+}
+
+func (cmd command) infof(tag string, format string, args ...any) {
+	f := fmt.Sprintf("%-10v %v", tag, format)
+
+	log.Run(cmd.runID).Infof(f, args...)
+}
+
+func (cmd command) warnf(tag string, format string, args ...any) {
+	f := fmt.Sprintf("%-10v %v", tag, format)
+
+	log.Run(cmd.runID).Warnf(f, args...)
+}
+
+func (cmd command) errorf(tag, format string, args ...any) {
+	f := fmt.Sprintf("%-10v %v", tag, format)
+
+	log.Run(cmd.runID).Errorf(f, args...)
+}
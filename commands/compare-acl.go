@@ -2,10 +2,14 @@ package commands
 
 import (
 	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
@@ -13,6 +17,7 @@ import (
 	core "github.com/uhppoted/uhppote-core/types"
 	"github.com/uhppoted/uhppote-core/uhppote"
 	"github.com/uhppoted/uhppoted-app-db/db"
+	"github.com/uhppoted/uhppoted-app-db/metrics"
 	lib "github.com/uhppoted/uhppoted-lib/acl"
 	"github.com/uhppoted/uhppoted-lib/config"
 )
@@ -21,7 +26,7 @@ var CompareACLCmd = CompareACL{
 	command: command{
 		name:        "compare-acl",
 		description: "Compares the access permissions in the configurated set of access controllers to an access control list in a database",
-		usage:       "[--with-pin] --dsn <DSN> [--table:ACL <table>] [-table:audit <table>] [-table:log <table>] [--file <file>]",
+		usage:       "[--with-pin [--pin-key <file>]] [--fix] [--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:groups <table> --table:members <table>] [-table:audit <table>] [-table:log <table>] [--format json|csv|tsv|text] [--template <file>] [--file <file>]",
 
 		dsn: "",
 		tables: tables{
@@ -34,14 +39,18 @@ var CompareACLCmd = CompareACL{
 		config:   config.DefaultConfig,
 	},
 
-	file:  "",
-	debug: false,
+	file:         "",
+	fix:          false,
+	reportFormat: "text",
+	templateFile: "",
+	debug:        false,
 
 	template: `ACL DIFF REPORT {{ .DateTime }}
 {{range $id,$value := .Diffs}}
   DEVICE {{ $id }}{{if or $value.Updated $value.Added $value.Deleted}}{{else}} OK{{end}}{{if $value.Updated}}
     Incorrect:  {{range $value.Updated}}{{.}}
-                {{end}}{{end}}{{if $value.Added}}
+                {{range index $.Details $id .CardNumber}}  {{.}}
+                {{end}}{{end}}{{end}}{{if $value.Added}}
     Missing:    {{range $value.Added}}{{.}}
                 {{end}}{{end}}{{if $value.Deleted}}
     Unexpected: {{range $value.Deleted}}{{.}}
@@ -51,14 +60,17 @@ var CompareACLCmd = CompareACL{
 
 type CompareACL struct {
 	command
-	file     string
-	template string
-	debug    bool
+	file         string
+	fix          bool
+	reportFormat string
+	template     string
+	templateFile string
+	debug        bool
 }
 
 func (cmd *CompareACL) Help() {
 	fmt.Println()
-	fmt.Printf("  Usage: %s [--debug] [--config <file>] compare-acl [--with-pin] [--file <file>] --dsn <DSN> [--table:ACL <table>] [-table:audit <table>] [-table:log <table>]\n", APP)
+	fmt.Printf("  Usage: %s [--debug] [--config <file>] compare-acl [--with-pin [--pin-key <file>]] [--fix] [--format json|csv|tsv|text] [--template <file>] [--file <file>] [--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:groups <table> --table:members <table>] [-table:audit <table>] [-table:log <table>]\n", APP)
 	fmt.Println()
 	fmt.Println("  Compares the access permissions in the configurated set of access controllers to an access control list in a database")
 	fmt.Println()
@@ -69,6 +81,13 @@ func (cmd *CompareACL) Help() {
 	fmt.Println("  Examples:")
 	fmt.Println(`    uhppote-app-db --debug compare-acl --with-pin --dsn "sqlite3://./db/ACL.db"`)
 	fmt.Println(`    uhppote-app-db --debug compare-acl --with-pin --dsn "sqlite3://./db/ACL.db" --table:ACL ACL2 --table:audit AuditTrail --table:log OpsLog`)
+	fmt.Println(`    uhppote-app-db compare-acl --dsn "sqlite3://./db/ACL.db" --format json --file compare.json`)
+	fmt.Println(`    uhppote-app-db compare-acl --fix --dsn "sqlite3://./db/ACL.db" --table:audit AuditTrail`)
+	fmt.Println()
+	fmt.Println("  Exit codes:")
+	fmt.Println("    0  controllers match the ACL")
+	fmt.Println("    1  differences found (or with --fix, differences that could not be fixed)")
+	fmt.Println("    2  error")
 	fmt.Println()
 }
 
@@ -80,8 +99,18 @@ func (cmd *CompareACL) FlagSet() *flag.FlagSet {
 	flagset.StringVar(&cmd.tables.Audit, "table:audit", cmd.tables.Audit, "Audit trail table name. Defaults to ''")
 	flagset.StringVar(&cmd.tables.Log, "table:log", cmd.tables.Log, "Operations log table name. Defaults to ''")
 	flagset.BoolVar(&cmd.withPIN, "with-pin", cmd.withPIN, "Include card keypad PIN code when comparing access controllers")
+	flagset.StringVar(&cmd.pinKey, "pin-key", cmd.pinKey, "File with the AES-256 key used to encrypt the card keypad PINs stored in the DB")
 	flagset.StringVar(&cmd.file, "file", cmd.file, "Optional filepath for compare report. Defaults to stdout")
+	flagset.BoolVar(&cmd.fix, "fix", cmd.fix, "Updates the controllers with the missing and incorrect cards and deletes unexpected cards")
+	flagset.StringVar(&cmd.reportFormat, "format", cmd.reportFormat, "Compare report format (json, csv, tsv or text). Defaults to text")
+	flagset.StringVar(&cmd.templateFile, "template", cmd.templateFile, "Optional Go text/template file for the text compare report")
+	flagset.StringVar(&cmd.tables.Groups, "table:groups", cmd.tables.Groups, "Optional access groups table name. Defaults to ''")
+	flagset.StringVar(&cmd.tables.Members, "table:members", cmd.tables.Members, "Optional access group members table name. Defaults to ''")
+	flagset.StringVar(&cmd.columns, "columns", cmd.columns, "Optional file with the ACL table column mapping. Defaults to the mapping in the configuration file")
 	flagset.StringVar(&cmd.lockfile, "lockfile", cmd.lockfile, "Filepath for lock file. Defaults to <tmp>/uhppoted-app-db.lock")
+	flagset.StringVar(&cmd.profile, "profile", cmd.profile, "Optional named profile from the configuration file with the DSN, tables and options")
+
+	cmd.flagset = flagset
 
 	return flagset
 }
@@ -89,24 +118,56 @@ func (cmd *CompareACL) FlagSet() *flag.FlagSet {
 func (cmd *CompareACL) Execute(args ...any) error {
 	options := args[0].(*Options)
 
+	cmd.started = time.Now()
+
+	if different, err := cmd.execute(options); err != nil {
+		metrics.Add(metrics.CompareACLRuns, metrics.Labels{"result": "failed"}, 1)
+		return ExitError{Code: ExitFailed, Err: err}
+	} else if different {
+		metrics.Add(metrics.CompareACLRuns, metrics.Labels{"result": "differences"}, 1)
+		return ExitError{Code: ExitDifferences}
+	}
+
+	metrics.Add(metrics.CompareACLRuns, metrics.Labels{"result": "ok"}, 1)
+
+	return nil
+}
+
+func (cmd *CompareACL) execute(options *Options) (bool, error) {
 	cmd.config = options.Config
 	cmd.debug = options.Debug
 
 	// ... check parameters
-	if strings.TrimSpace(cmd.dsn) == "" {
-		return fmt.Errorf("invalid database DSN")
+	if err := cmd.resolve(); err != nil {
+		return false, err
 	}
 
 	if strings.TrimSpace(cmd.tables.ACL) == "" {
-		return fmt.Errorf("invalid ACL table")
+		return false, fmt.Errorf("invalid ACL table")
+	}
+
+	switch cmd.reportFormat {
+	case "json", "csv", "tsv", "text":
+	default:
+		return false, fmt.Errorf("invalid report format (%v)", cmd.reportFormat)
+	}
+
+	if cmd.templateFile != "" {
+		if cmd.reportFormat != "text" {
+			return false, fmt.Errorf("--template is only valid for the 'text' report format")
+		} else if bytes, err := os.ReadFile(cmd.templateFile); err != nil {
+			return false, err
+		} else {
+			cmd.template = string(bytes)
+		}
 	}
 
 	// ... locked?
 	if kraken, err := lock(cmd.lockfile); err != nil {
-		return err
+		return false, err
 	} else {
 		defer func() {
-			infof("compare-acl", "removing lockfile")
+			cmd.infof("compare-acl", "removing lockfile")
 			kraken.Release()
 		}()
 	}
@@ -114,10 +175,22 @@ func (cmd *CompareACL) Execute(args ...any) error {
 	// ... get config
 	conf := config.NewConfig()
 	if err := conf.Load(cmd.config); err != nil {
-		return fmt.Errorf("could not load configuration (%v)", err)
+		return false, fmt.Errorf("could not load configuration (%v)", err)
 	}
 
-	u, devices := getDevices(conf, cmd.debug)
+	u, devices := getDevices(conf, cmd.timeout, cmd.debug)
+
+	// ... get ACL table column mapping
+	columns, err := cmd.getColumns()
+	if err != nil {
+		return false, err
+	}
+
+	// ... get PIN key
+	key, err := cmd.loadPINKey()
+	if err != nil {
+		return false, err
+	}
 
 	// ... retrieve ACL from DB
 	f := func(table lib.Table, devices []uhppote.Device) (*lib.ACL, []error, error) {
@@ -128,63 +201,90 @@ func (cmd *CompareACL) Execute(args ...any) error {
 		}
 	}
 
-	if table, err := getACL(cmd.dsn, cmd.tables.ACL, cmd.withPIN); err != nil {
-		return err
+	if table, err := getACL(cmd.dsn, cmd.tls, cmd.tables.ACL, columns, cmd.withPIN); err != nil {
+		return false, err
+	} else if err := decryptPINs(&table, key); err != nil {
+		return false, err
+	} else if table, err := expand(cmd.dsn, cmd.tls, cmd.tables, columns, table); err != nil {
+		return false, err
+	} else if table, _, err := activeCards(table); err != nil {
+		return false, err
+	} else if table, scoped, err := scopes(table); err != nil {
+		return false, err
 	} else if acl, warnings, err := f(table, devices); err != nil {
-		return err
+		return false, err
 	} else if acl == nil {
-		return fmt.Errorf("error creating ACL from DB table (%v)", acl)
+		return false, fmt.Errorf("error creating ACL from DB table (%v)", acl)
 	} else {
+		restrict(*acl, scoped)
+
 		if cmd.debug {
 			acl.Print(os.Stdout)
 		}
 
 		for _, w := range warnings {
-			warnf("compare-acl", "%v", w)
+			cmd.warnf("compare-acl", "%v", w)
 		}
 
-		diff, err := cmd.compare(u, devices, *acl)
+		diff, details, err := cmd.compare(u, devices, *acl)
 		if err != nil {
-			return err
+			return false, err
+		}
+
+		diffMetrics(diff)
+
+		if cmd.pinKey != "" {
+			maskPINs(details)
 		}
 
-		bytes, err := cmd.format(diff)
+		bytes, err := cmd.format(diff, details)
 		if err != nil {
-			return err
+			return false, err
 		}
 
+		fixes := []db.AuditRecord{}
+		if cmd.fix {
+			fixes = cmd.remediate(u, diff)
+		}
+
+		cmd.notifyDiff(diff)
+
 		if cmd.tables.Audit != "" {
-			recordset := diff2audit(diff, cmd.withPIN)
-			if err := stashToAudit(cmd.dsn, cmd.tables.Audit, recordset); err != nil {
-				return err
+			recordset := append(diff2audit(diff, details, cmd.showPIN()), fixes...)
+			if err := cmd.stashToAudit(recordset); err != nil {
+				return false, err
 			}
 		}
 
 		if cmd.tables.Log != "" {
-			recordset := diff2log(diff)
-			if err := stashToLog(cmd.dsn, cmd.tables.Log, recordset); err != nil {
-				return err
+			recordset := append(diff2log(diff), fixes2log(fixes)...)
+			if err := cmd.stashToLog(elapsed(recordset, cmd.started)); err != nil {
+				return false, err
 			}
 		}
 
 		if cmd.file != "" {
 			if err := os.MkdirAll(filepath.Dir(cmd.file), 0750); err != nil {
-				return err
+				return false, err
 			} else if err := os.WriteFile(cmd.file, bytes, 0660); err != nil {
-				return err
+				return false, err
 			}
 		} else if _, err := fmt.Printf("%v", string(bytes)); err != nil {
-			return err
+			return false, err
 		}
-	}
 
-	return nil
+		if cmd.fix {
+			return !fixed(fixes), nil
+		}
+
+		return different(diff), nil
+	}
 }
 
-func (cmd *CompareACL) compare(u uhppote.IUHPPOTE, devices []uhppote.Device, acl lib.ACL) (lib.SystemDiff, error) {
+func (cmd *CompareACL) compare(u uhppote.IUHPPOTE, devices []uhppote.Device, acl lib.ACL) (lib.SystemDiff, diffDetails, error) {
 	current, errors := lib.GetACL(u, devices)
 	if len(errors) > 0 {
-		return lib.SystemDiff{}, fmt.Errorf("%v", errors)
+		return lib.SystemDiff{}, nil, fmt.Errorf("%v", errors)
 	}
 
 	f := func() (map[uint32]lib.Diff, error) {
@@ -196,13 +296,93 @@ func (cmd *CompareACL) compare(u uhppote.IUHPPOTE, devices []uhppote.Device, acl
 	}
 
 	if d, err := f(); err != nil {
-		return lib.SystemDiff{}, err
+		return lib.SystemDiff{}, nil, err
 	} else {
-		return lib.SystemDiff(d), nil
+		return lib.SystemDiff(d), fieldDiffs(current, d, cmd.withPIN), nil
 	}
 }
 
-func (cmd *CompareACL) format(diff map[uint32]lib.Diff) ([]byte, error) {
+// remediate applies the corrections in a compare report to the affected controllers i.e. puts the
+// missing and incorrect cards and deletes the unexpected cards. The controller PIN of an incorrect card
+// is retained unless the compare included the PIN.
+func (cmd *CompareACL) remediate(u uhppote.IUHPPOTE, diff lib.SystemDiff) []db.AuditRecord {
+	recordset := []db.AuditRecord{}
+
+	put := func(controller uint32, card core.Card) (bool, error) {
+		if !cmd.withPIN {
+			if existing, err := u.GetCardByID(controller, card.CardNumber); err != nil {
+				return false, err
+			} else if existing != nil {
+				card.PIN = existing.PIN
+			} else {
+				card.PIN = 0
+			}
+		}
+
+		return u.PutCard(controller, card)
+	}
+
+	auditRecord := func(controller uint32, card core.Card, ok bool, err error) db.AuditRecord {
+		status := "fixed"
+		if err != nil {
+			cmd.warnf("compare-acl", "%v  card %v: fix failed (%v)", controller, card.CardNumber, err)
+			status = "fix-failed"
+		} else if !ok {
+			cmd.warnf("compare-acl", "%v  card %v: fix failed", controller, card.CardNumber)
+			status = "fix-failed"
+		} else {
+			cmd.infof("compare-acl", "%v  card %v: fixed", controller, card.CardNumber)
+		}
+
+		return db.AuditRecord{
+			Timestamp:  time.Now(),
+			Operation:  "fix",
+			Controller: controller,
+			CardNumber: card.CardNumber,
+			Status:     status,
+			Card:       format(card, cmd.showPIN()),
+		}
+	}
+
+	for _, controller := range slices.Sorted(maps.Keys(diff)) {
+		v := diff[controller]
+
+		for _, card := range v.Updated {
+			ok, err := put(controller, card)
+			recordset = append(recordset, auditRecord(controller, card, ok, err))
+		}
+
+		for _, card := range v.Added {
+			ok, err := put(controller, card)
+			recordset = append(recordset, auditRecord(controller, card, ok, err))
+		}
+
+		for _, card := range v.Deleted {
+			ok, err := u.DeleteCard(controller, card.CardNumber)
+			recordset = append(recordset, auditRecord(controller, card, ok, err))
+		}
+	}
+
+	return recordset
+}
+
+func (cmd *CompareACL) format(diff map[uint32]lib.Diff, details diffDetails) ([]byte, error) {
+	switch cmd.reportFormat {
+	case "json":
+		return diff2json(diff, details, cmd.showPIN())
+
+	case "csv":
+		return diff2csv(diff, details, ',', cmd.showPIN())
+
+	case "tsv":
+		return diff2csv(diff, details, '\t', cmd.showPIN())
+
+	default:
+		return cmd.text(diff, details)
+	}
+}
+
+func (cmd *CompareACL) text(diff map[uint32]lib.Diff, details diffDetails) ([]byte, error) {
 	var b bytes.Buffer
 
 	t, err := template.New("report").Parse(cmd.template)
@@ -213,9 +393,11 @@ func (cmd *CompareACL) format(diff map[uint32]lib.Diff) ([]byte, error) {
 	rpt := struct {
 		DateTime string
 		Diffs    map[uint32]lib.Diff
+		Details  diffDetails
 	}{
 		DateTime: time.Now().Format("2006-01-02 15:04:05"),
 		Diffs:    diff,
+		Details:  details,
 	}
 
 	if err := t.Execute(&b, rpt); err != nil {
@@ -225,7 +407,153 @@ func (cmd *CompareACL) format(diff map[uint32]lib.Diff) ([]byte, error) {
 	}
 }
 
-func diff2audit(diff lib.SystemDiff, withPIN bool) []db.AuditRecord {
+func diff2json(diff map[uint32]lib.Diff, details diffDetails, withPIN bool) ([]byte, error) {
+	type controller struct {
+		Controller  uint32                  `json:"controller"`
+		InSync      bool                    `json:"in-sync"`
+		Unchanged   int                     `json:"unchanged"`
+		Incorrect   []core.Card             `json:"incorrect"`
+		Missing     []core.Card             `json:"missing"`
+		Unexpected  []core.Card             `json:"unexpected"`
+		Differences map[uint32][]difference `json:"differences"`
+	}
+
+	cards := func(list []core.Card) []core.Card {
+		cards := []core.Card{}
+		for _, card := range list {
+			if !withPIN {
+				card.PIN = 0
+			}
+
+			cards = append(cards, card)
+		}
+
+		return cards
+	}
+
+	report := struct {
+		Timestamp   string       `json:"timestamp"`
+		InSync      bool         `json:"in-sync"`
+		Controllers []controller `json:"controllers"`
+	}{
+		Timestamp:   time.Now().Format("2006-01-02 15:04:05"),
+		InSync:      !different(diff),
+		Controllers: []controller{},
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(diff)) {
+		v := diff[id]
+
+		differences := map[uint32][]difference{}
+		for card, list := range details[id] {
+			differences[card] = list
+		}
+
+		report.Controllers = append(report.Controllers, controller{
+			Controller:  id,
+			InSync:      len(v.Updated) == 0 && len(v.Added) == 0 && len(v.Deleted) == 0,
+			Unchanged:   len(v.Unchanged),
+			Incorrect:   cards(v.Updated),
+			Missing:     cards(v.Added),
+			Unexpected:  cards(v.Deleted),
+			Differences: differences,
+		})
+	}
+
+	if bytes, err := json.MarshalIndent(report, "", "  "); err != nil {
+		return nil, err
+	} else {
+		return append(bytes, '\n'), nil
+	}
+}
+
+func diff2csv(diff map[uint32]lib.Diff, details diffDetails, delimiter rune, withPIN bool) ([]byte, error) {
+	var b bytes.Buffer
+
+	w := csv.NewWriter(&b)
+	w.Comma = delimiter
+
+	header := []string{"Controller", "CardNumber", "Status", "From", "To", "Door1", "Door2", "Door3", "Door4"}
+	if withPIN {
+		header = append(header, "PIN")
+	}
+
+	header = append(header, "Field", "DBValue", "ControllerValue")
+
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	row := func(controller uint32, card core.Card, status string, d difference) []string {
+		record := []string{
+			fmt.Sprintf("%v", controller),
+			fmt.Sprintf("%v", card.CardNumber),
+			status,
+			fmt.Sprintf("%v", card.From),
+			fmt.Sprintf("%v", card.To),
+			permission(card.Doors[1]),
+			permission(card.Doors[2]),
+			permission(card.Doors[3]),
+			permission(card.Doors[4]),
+		}
+
+		if withPIN {
+			record = append(record, fmt.Sprintf("%v", card.PIN))
+		}
+
+		return append(record, d.Field, d.DB, d.Controller)
+	}
+
+	for _, controller := range slices.Sorted(maps.Keys(diff)) {
+		v := diff[controller]
+
+		for _, card := range v.Updated {
+			list := details[controller][card.CardNumber]
+			if len(list) == 0 {
+				list = []difference{{}}
+			}
+
+			for _, d := range list {
+				if err := w.Write(row(controller, card, "incorrect", d)); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for _, card := range v.Added {
+			if err := w.Write(row(controller, card, "missing", difference{})); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, card := range v.Deleted {
+			if err := w.Write(row(controller, card, "unexpected", difference{})); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// different returns true if any controller has incorrect, missing or unexpected cards.
+func different(diff map[uint32]lib.Diff) bool {
+	for _, v := range diff {
+		if len(v.Updated) > 0 || len(v.Added) > 0 || len(v.Deleted) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func diff2audit(diff lib.SystemDiff, details diffDetails, withPIN bool) []db.AuditRecord {
 	now := time.Now()
 	recordset := []db.AuditRecord{}
 
@@ -242,7 +570,12 @@ func diff2audit(diff lib.SystemDiff, withPIN bool) []db.AuditRecord {
 
 	for controller, v := range diff {
 		for _, card := range v.Updated {
-			recordset = append(recordset, auditRecord(controller, card, "incorrect"))
+			record := auditRecord(controller, card, "incorrect")
+			if list := details[controller][card.CardNumber]; len(list) > 0 {
+				record.Card = fmt.Sprintf("%v  %v", record.Card, summarise(list))
+			}
+
+			recordset = append(recordset, record)
 		}
 
 		for _, card := range v.Added {
@@ -273,6 +606,12 @@ func diff2log(diff lib.SystemDiff) []db.LogRecord {
 			Operation:  "compare-acl",
 			Controller: controller,
 			Detail:     detail,
+			Counters: map[string]int{
+				"unchanged": len(row.Unchanged),
+				"incorrect": len(row.Updated),
+				"missing":   len(row.Added),
+				"extra":     len(row.Deleted),
+			},
 		}
 	}
 
@@ -283,36 +622,69 @@ func diff2log(diff lib.SystemDiff) []db.LogRecord {
 	return recordset
 }
 
-func format(c core.Card, withPIN bool) string {
-	door := func(door uint8, access uint8) string {
-		switch access {
-		case 0:
-			return "N"
-		case 1:
-			return "Y"
-		default:
-			return fmt.Sprintf("%v", access)
+func fixes2log(fixes []db.AuditRecord) []db.LogRecord {
+	now := time.Now()
+	recordset := []db.LogRecord{}
+	summary := map[uint32]*struct{ fixed, failed int }{}
+
+	for _, r := range fixes {
+		if _, ok := summary[r.Controller]; !ok {
+			summary[r.Controller] = &struct{ fixed, failed int }{}
+		}
+
+		if r.Status == "fixed" {
+			summary[r.Controller].fixed++
+		} else {
+			summary[r.Controller].failed++
 		}
 	}
 
+	for _, controller := range slices.Sorted(maps.Keys(summary)) {
+		recordset = append(recordset, db.LogRecord{
+			Timestamp:  now,
+			Operation:  "fix-acl",
+			Controller: controller,
+			Detail:     fmt.Sprintf("fixed:%-4v failed:%-4v", summary[controller].fixed, summary[controller].failed),
+			Counters: map[string]int{
+				"fixed":  summary[controller].fixed,
+				"failed": summary[controller].failed,
+			},
+		})
+	}
+
+	return recordset
+}
+
+// fixed returns true if every correction in the list was applied successfully.
+func fixed(fixes []db.AuditRecord) bool {
+	for _, r := range fixes {
+		if r.Status != "fixed" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func format(c core.Card, withPIN bool) string {
 	if withPIN {
 		return fmt.Sprintf("%-10v %-10s %-10s %-3v %-3v %-3v %-3v %-5v",
 			c.CardNumber,
 			c.From,
 			c.To,
-			door(1, c.Doors[1]),
-			door(2, c.Doors[2]),
-			door(3, c.Doors[3]),
-			door(4, c.Doors[4]),
+			permission(c.Doors[1]),
+			permission(c.Doors[2]),
+			permission(c.Doors[3]),
+			permission(c.Doors[4]),
 			c.PIN)
 	} else {
 		return fmt.Sprintf("%-10v %-10s %-10s %-3v %-3v %-3v %-3v",
 			c.CardNumber,
 			c.From,
 			c.To,
-			door(1, c.Doors[1]),
-			door(2, c.Doors[2]),
-			door(3, c.Doors[3]),
-			door(4, c.Doors[4]))
+			permission(c.Doors[1]),
+			permission(c.Doors[2]),
+			permission(c.Doors[3]),
+			permission(c.Doors[4]))
 	}
 }
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"testing"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+)
+
+var reportDiffs = map[uint32]lib.Diff{
+	405419896: {
+		Unchanged: []core.Card{reportCard(10058400, 1, 0, 7531)},
+		Updated:   []core.Card{reportCard(10058401, 1, 29, 7531)},
+		Added:     []core.Card{reportCard(10058402, 1, 1, 0)},
+		Deleted:   []core.Card{reportCard(10058403, 0, 0, 0)},
+	},
+	303986753: {
+		Unchanged: []core.Card{reportCard(10058400, 1, 0, 7531)},
+	},
+}
+
+var reportDetails = diffDetails{
+	405419896: {
+		10058401: {{"Door 2", "29", "Y"}},
+	},
+}
+
+func reportCard(number uint32, door1, door2 uint8, pin uint32) core.Card {
+	return core.Card{
+		CardNumber: number,
+		From:       core.MustParseDate("2025-01-01"),
+		To:         core.MustParseDate("2025-12-31"),
+		Doors:      map[uint8]uint8{1: door1, 2: door2, 3: 0, 4: 0},
+		PIN:        core.PIN(pin),
+	}
+}
+
+func TestDiff2CSV(t *testing.T) {
+	expected := `Controller	CardNumber	Status	From	To	Door1	Door2	Door3	Door4	PIN	Field	DBValue	ControllerValue
+405419896	10058401	incorrect	2025-01-01	2025-12-31	Y	29	N	N	7531	Door 2	29	Y
+405419896	10058402	missing	2025-01-01	2025-12-31	Y	Y	N	N	0			
+405419896	10058403	unexpected	2025-01-01	2025-12-31	N	N	N	N	0			
+`
+
+	if bytes, err := diff2csv(reportDiffs, reportDetails, '\t', true); err != nil {
+		t.Fatalf("%v", err)
+	} else if string(bytes) != expected {
+		t.Errorf("incorrect report\n   expected:\n%v\n   got:\n%v", expected, string(bytes))
+	}
+}
+
+func TestDifferent(t *testing.T) {
+	unchanged := map[uint32]lib.Diff{
+		303986753: reportDiffs[303986753],
+	}
+
+	if different(unchanged) {
+		t.Errorf("expected no differences for unchanged ACL")
+	}
+
+	if !different(reportDiffs) {
+		t.Errorf("expected differences for incorrect, missing and unexpected cards")
+	}
+}
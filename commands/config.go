@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppoted-lib/encoding/conf"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+	"github.com/uhppoted/uhppoted-app-db/notify"
+)
+
+// settings holds the uhppoted-app-db specific configuration, which is read from uhppoted.conf (or a
+// separate file) using the same 'key = value' format e.g.
+//
+//	db.acl.columns.card-number = EmployeeCard
+//	db.acl.columns.door.FrontDoor = Front Door
+//	db.acl.columns.ignore = Department, EmployeeID, Notes
+//	db.notify.webhook.url = http://localhost:8080/uhppoted
+//	db.secret.PASSWORD = pass show uhppoted/db
+//	db.tls.mode = verify-full
+//	db.capacity.default = 20000
+type settings struct {
+	Profiles    profiles   `conf:"db.profile"`
+	ProfileFile string     `conf:"db.profile-file"`
+	Capacity    capacities `conf:"db.capacity"`
+
+	DB struct {
+		DSN     string  `conf:"dsn"`
+		DSNFile string  `conf:"dsn-file"`
+		Secrets secrets `conf:"secret"`
+		TLS     struct {
+			Mode        string `conf:"mode"`
+			CA          string `conf:"ca"`
+			Certificate string `conf:"certificate"`
+			Key         string `conf:"key"`
+			ServerName  string `conf:"server-name"`
+		} `conf:"tls"`
+	} `conf:"db"`
+
+	ACL struct {
+		Columns columns `conf:"columns"`
+	} `conf:"db.acl"`
+
+	Notify struct {
+		Threshold   int           `conf:"threshold"`
+		QuietPeriod time.Duration `conf:"quiet-period"`
+		State       string        `conf:"state"`
+		SMTP        struct {
+			Server   string `conf:"server"`
+			Username string `conf:"username"`
+			Password string `conf:"password"`
+			From     string `conf:"from"`
+			To       string `conf:"to"`
+		} `conf:"smtp"`
+		Webhook struct {
+			URL string `conf:"url"`
+		} `conf:"webhook"`
+	} `conf:"db.notify"`
+}
+
+type columns struct {
+	CardNumber string  `conf:"card-number"`
+	PIN        string  `conf:"PIN"`
+	From       string  `conf:"from"`
+	To         string  `conf:"to"`
+	Status     string  `conf:"status"`
+	Scope      string  `conf:"controllers"`
+	Doors      doormap `conf:"door"`
+	Ignore     string  `conf:"ignore"`
+}
+
+type doormap map[string]string
+
+func (m *doormap) UnmarshalConf(tag string, values map[string]string) (any, error) {
+	doors := doormap{}
+	prefix := tag + "."
+
+	for k, v := range values {
+		if column, ok := strings.CutPrefix(k, prefix); ok && strings.TrimSpace(column) != "" {
+			doors[strings.TrimSpace(column)] = strings.TrimSpace(v)
+		}
+	}
+
+	return &doors, nil
+}
+
+// secrets maps DSN placeholder names to the commands that print the secret values.
+type secrets map[string]string
+
+func (m *secrets) UnmarshalConf(tag string, values map[string]string) (any, error) {
+	v, err := (&doormap{}).UnmarshalConf(tag, values)
+	if err != nil {
+		return nil, err
+	}
+
+	s := secrets(*v.(*doormap))
+
+	return &s, nil
+}
+
+func loadSettings(file string) (*settings, error) {
+	s := settings{}
+
+	if file == "" {
+		return &s, nil
+	}
+
+	if bytes, err := os.ReadFile(file); err != nil && errors.Is(err, os.ErrNotExist) {
+		return &s, nil
+	} else if err != nil {
+		return nil, err
+	} else if err := conf.Unmarshal(bytes, &s); err != nil {
+		return nil, fmt.Errorf("error reading settings from %v (%v)", file, err)
+	}
+
+	return &s, nil
+}
+
+// getColumns returns the ACL table column mapping from the --columns file if specified, falling back
+// to uhppoted.conf and then to the default ACL table columns.
+func (cmd command) getColumns() (db.Columns, error) {
+	file := cmd.config
+	if cmd.columns != "" {
+		file = cmd.columns
+	}
+
+	s, err := loadSettings(file)
+	if err != nil {
+		return db.Columns{}, err
+	}
+
+	mapping := db.Columns{
+		CardNumber:  db.DefaultColumns.CardNumber,
+		PIN:         db.DefaultColumns.PIN,
+		StartDate:   db.DefaultColumns.StartDate,
+		EndDate:     db.DefaultColumns.EndDate,
+		Status:      db.DefaultColumns.Status,
+		Controllers: db.DefaultColumns.Controllers,
+		Doors:       map[string]string{},
+		Ignore:      append([]string{}, db.DefaultColumns.Ignore...),
+	}
+
+	if v := strings.TrimSpace(s.ACL.Columns.CardNumber); v != "" {
+		mapping.CardNumber = v
+	}
+
+	if v := strings.TrimSpace(s.ACL.Columns.PIN); v != "" {
+		mapping.PIN = v
+	}
+
+	if v := strings.TrimSpace(s.ACL.Columns.From); v != "" {
+		mapping.StartDate = v
+	}
+
+	if v := strings.TrimSpace(s.ACL.Columns.To); v != "" {
+		mapping.EndDate = v
+	}
+
+	if v := strings.TrimSpace(s.ACL.Columns.Status); v != "" {
+		mapping.Status = v
+	}
+
+	if v := strings.TrimSpace(s.ACL.Columns.Scope); v != "" {
+		mapping.Controllers = v
+	}
+
+	for k, v := range s.ACL.Columns.Doors {
+		mapping.Doors[k] = v
+	}
+
+	for v := range strings.SplitSeq(s.ACL.Columns.Ignore, ",") {
+		if column := strings.TrimSpace(v); column != "" {
+			mapping.Ignore = append(mapping.Ignore, column)
+		}
+	}
+
+	return mapping, nil
+}
+
+// getNotifications returns the notification settings from uhppoted.conf.
+func (cmd command) getNotifications() (notify.Config, error) {
+	s, err := loadSettings(cmd.config)
+	if err != nil {
+		return notify.Config{}, err
+	}
+
+	config := notify.Config{
+		Threshold:   s.Notify.Threshold,
+		QuietPeriod: s.Notify.QuietPeriod,
+		State:       strings.TrimSpace(s.Notify.State),
+		SMTP: notify.SMTP{
+			Server:   strings.TrimSpace(s.Notify.SMTP.Server),
+			Username: strings.TrimSpace(s.Notify.SMTP.Username),
+			Password: s.Notify.SMTP.Password,
+			From:     strings.TrimSpace(s.Notify.SMTP.From),
+			To:       []string{},
+		},
+		Webhook: notify.Webhook{
+			URL: strings.TrimSpace(s.Notify.Webhook.URL),
+		},
+	}
+
+	for v := range strings.SplitSeq(s.Notify.SMTP.To, ",") {
+		if to := strings.TrimSpace(v); to != "" {
+			config.SMTP.To = append(config.SMTP.To, to)
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetColumns(t *testing.T) {
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "uhppoted.conf")
+	columns := filepath.Join(dir, "columns.conf")
+
+	if err := os.WriteFile(conf, []byte("db.acl.columns.card-number = EmployeeCard\ndb.acl.columns.door.Lab = Lab\ndb.acl.columns.ignore = Department,, Notes\n"), 0600); err != nil {
+		t.Fatalf("%v", err)
+	} else if err := os.WriteFile(columns, []byte("db.acl.columns.card-number = Badge\n"), 0600); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	cmd := command{config: conf}
+	if mapping, err := cmd.getColumns(); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if mapping.CardNumber != "EmployeeCard" || mapping.StartDate != "StartDate" {
+		t.Errorf("incorrect column mapping %+v", mapping)
+	} else if !reflect.DeepEqual(mapping.Doors, map[string]string{"Lab": "Lab"}) {
+		t.Errorf("incorrect door columns - expected:%v, got:%v", map[string]string{"Lab": "Lab"}, mapping.Doors)
+	} else if !reflect.DeepEqual(mapping.Ignore, []string{"Name", "Department", "Notes"}) {
+		t.Errorf("incorrect ignored columns - expected:%v, got:%v", []string{"Name", "Department", "Notes"}, mapping.Ignore)
+	}
+
+	// ... --columns file replaces the uhppoted.conf mapping
+	cmd = command{config: conf, columns: columns}
+	if mapping, err := cmd.getColumns(); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if mapping.CardNumber != "Badge" || len(mapping.Doors) != 0 {
+		t.Errorf("incorrect column mapping %+v", mapping)
+	}
+}
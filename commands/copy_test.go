@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCopyEvents(t *testing.T) {
+	dir := t.TempDir()
+	schema := `CREATE TABLE Events (
+	    Controller   INTEGER  NOT NULL,
+	    EventIndex   INTEGER  NOT NULL,
+	    Timestamp    DATETIME NULL,
+	    Type         INTEGER  NULL,
+	    Granted      INTEGER  NULL,
+	    Door         INTEGER  NULL,
+	    Direction    INTEGER  NULL,
+	    CardNumber   INTEGER  NULL,
+	    Reason       INTEGER  NULL,
+	    CONSTRAINT ControllerEventIndex UNIQUE (Controller, EventIndex) ON CONFLICT REPLACE
+	);`
+
+	create := func(file string, statements ...string) {
+		t.Helper()
+
+		if dbc, err := sql.Open("sqlite3", file); err != nil {
+			t.Fatalf("%v", err)
+		} else {
+			defer dbc.Close()
+
+			for _, s := range append([]string{schema}, statements...) {
+				if _, err := dbc.Exec(s); err != nil {
+					t.Fatalf("%v", err)
+				}
+			}
+		}
+	}
+
+	src := filepath.Join(dir, "src.db")
+	dest := filepath.Join(dir, "dest.db")
+
+	create(src,
+		`INSERT INTO Events VALUES (405419896, 1, '2025-01-01 12:00:01', 2, 1, 1, 1, 10058400, 1);`,
+		`INSERT INTO Events VALUES (405419896, 2, '2025-01-01 12:00:02', 2, 1, 1, 1, 10058400, 1);`,
+		`INSERT INTO Events VALUES (405419896, 3, '2025-01-01 12:00:03', 2, 1, 1, 1, 10058400, 1);`,
+		`INSERT INTO Events VALUES (303986753, 7, '2025-01-01 12:00:04', 2, 0, 2, 1, 10058401, 6);`)
+
+	create(dest,
+		`INSERT INTO Events VALUES (405419896, 2, '2025-01-01 12:00:02', 2, 1, 1, 1, 10058400, 1);`)
+
+	cmd := CopyEventsCmd
+	cmd.dsn = "sqlite3://" + src
+	cmd.to = "sqlite3://" + dest
+
+	expected := [][2]uint32{
+		{303986753, 7},
+		{405419896, 2},
+		{405419896, 3},
+	}
+
+	// ... copy twice to check that the second copy is a no-op
+	for _, count := range []int{2, 0} {
+		if N, err := cmd.copyEvents("Events", "Events"); err != nil {
+			t.Fatalf("%v", err)
+		} else if N != count {
+			t.Errorf("incorrect number of copied events - expected:%v, got:%v", count, N)
+		}
+
+		if events := readEvents(t, dest); !reflect.DeepEqual(events, expected) {
+			t.Errorf("incorrect destination events\n   expected:%v\n   got:     %v", expected, events)
+		}
+	}
+}
+
+func readEvents(t *testing.T, file string) [][2]uint32 {
+	t.Helper()
+
+	dbc, err := sql.Open("sqlite3", file)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	defer dbc.Close()
+
+	rs, err := dbc.Query(`SELECT Controller, EventIndex FROM Events ORDER BY Controller, EventIndex`)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	defer rs.Close()
+
+	events := [][2]uint32{}
+	for rs.Next() {
+		var e [2]uint32
+		if err := rs.Scan(&e[0], &e[1]); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		events = append(events, e)
+	}
+
+	return events
+}
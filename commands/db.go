@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	core "github.com/uhppoted/uhppote-core/types"
 	lib "github.com/uhppoted/uhppoted-lib/acl"
@@ -12,31 +13,32 @@ import (
 	"github.com/uhppoted/uhppoted-app-db/db/mysql"
 	"github.com/uhppoted/uhppoted-app-db/db/postgres"
 	"github.com/uhppoted/uhppoted-app-db/db/sqlite3"
+	"github.com/uhppoted/uhppoted-app-db/log"
 )
 
-func fromDSN(dsn string) (db.DB, error) {
+func fromDSN(dsn string, tls db.TLS) (db.DB, error) {
 	switch {
 	case strings.HasPrefix(dsn, "sqlite3://"):
-		return sqlite3.NewDB(dsn[10:]), nil
+		return instrumented{sqlite3.NewDB(dsn[10:]), "sqlite3"}, nil
 
 	case strings.HasPrefix(dsn, "sqlserver://"):
-		return mssql.NewDB(dsn), nil
+		return instrumented{mssql.NewDB(dsn, tls), "mssql"}, nil
 
 	case strings.HasPrefix(dsn, "mysql://"):
-		return mysql.NewDB(dsn[8:]), nil
+		return instrumented{mysql.NewDB(dsn[8:], tls), "mysql"}, nil
 
 	case strings.HasPrefix(dsn, "postgresql://"):
-		return postgres.NewDB(dsn), nil
+		return instrumented{postgres.NewDB(dsn, tls), "postgres"}, nil
 
 	default:
 		return nil, fmt.Errorf("unsupported DSN (%v)", dsn)
 	}
 }
 
-func getACL(dsn string, table string, withPIN bool) (lib.Table, error) {
-	if dbi, err := fromDSN(dsn); err != nil {
+func getACL(dsn string, tls db.TLS, table string, columns db.Columns, withPIN bool) (lib.Table, error) {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
 		return lib.Table{}, err
-	} else if t, err := dbi.GetACL(table, withPIN); err != nil {
+	} else if t, err := dbi.GetACL(table, columns, withPIN); err != nil {
 		return lib.Table{}, err
 	} else if t == nil {
 		return lib.Table{}, fmt.Errorf("invalid ACL table (%v)", t)
@@ -45,10 +47,10 @@ func getACL(dsn string, table string, withPIN bool) (lib.Table, error) {
 	}
 }
 
-func putACL(dsn string, table string, acl lib.Table, withPIN bool) error {
-	if dbi, err := fromDSN(dsn); err != nil {
+func putACL(dsn string, tls db.TLS, table string, acl lib.Table, columns db.Columns, withPIN bool) error {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
 		return err
-	} else if N, err := dbi.PutACL(table, acl, withPIN); err != nil {
+	} else if N, err := dbi.PutACL(table, acl, columns, withPIN); err != nil {
 		return err
 	} else if N == 1 {
 		infof("put-acl", "Stored %v card to DB ACL table", N)
@@ -59,8 +61,76 @@ func putACL(dsn string, table string, acl lib.Table, withPIN bool) error {
 	return nil
 }
 
-func getEvents(dsn string, table string, controller uint32) ([]uint32, error) {
-	if dbi, err := fromDSN(dsn); err != nil {
+func getGroups(dsn string, tls db.TLS, table string, columns db.Columns) ([]db.Group, error) {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
+		return nil, err
+	} else if groups, err := dbi.GetGroups(table, columns); err != nil {
+		return nil, err
+	} else {
+		return groups, nil
+	}
+}
+
+func getGroupMembers(dsn string, tls db.TLS, table string) ([]db.GroupMember, error) {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
+		return nil, err
+	} else if members, err := dbi.GetGroupMembers(table); err != nil {
+		return nil, err
+	} else {
+		return members, nil
+	}
+}
+
+func getTimeProfiles(dsn string, tls db.TLS, table string) ([]core.TimeProfile, error) {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
+		return nil, err
+	} else if profiles, err := dbi.GetTimeProfiles(table); err != nil {
+		return nil, err
+	} else {
+		return profiles, nil
+	}
+}
+
+func getHolidays(dsn string, tls db.TLS, table string) ([]db.Holiday, error) {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
+		return nil, err
+	} else if holidays, err := dbi.GetHolidays(table); err != nil {
+		return nil, err
+	} else {
+		return holidays, nil
+	}
+}
+
+func query(dsn string, tls db.TLS, table string, filter db.Filter) ([]db.Record, error) {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
+		return nil, err
+	} else if records, err := dbi.Query(table, filter); err != nil {
+		return nil, err
+	} else {
+		return records, nil
+	}
+}
+
+func checkPermissions(dsn string, tls db.TLS, table string, column string, operations []db.Operation) ([]db.Permission, error) {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
+		return nil, err
+	} else if permissions, err := dbi.CheckPermissions(table, column, operations); err != nil {
+		return nil, err
+	} else {
+		return permissions, nil
+	}
+}
+
+func expireCards(dsn string, tls db.TLS, table string, columns db.Columns, cards []uint32, status string) (int, error) {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
+		return 0, err
+	} else {
+		return dbi.ExpireCards(table, columns, cards, status)
+	}
+}
+
+func getEvents(dsn string, tls db.TLS, table string, controller uint32) ([]uint32, error) {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
 		return nil, err
 	} else if events, err := dbi.GetEvents(table, controller); err != nil {
 		return nil, err
@@ -69,8 +139,8 @@ func getEvents(dsn string, table string, controller uint32) ([]uint32, error) {
 	}
 }
 
-func putEvents(dsn string, table string, events []core.Event) error {
-	if dbi, err := fromDSN(dsn); err != nil {
+func putEvents(dsn string, tls db.TLS, table string, events []core.Event) error {
+	if dbi, err := fromDSN(dsn, tls); err != nil {
 		return err
 	} else if N, err := dbi.PutEvents(table, events); err != nil {
 		return err
@@ -83,29 +153,52 @@ func putEvents(dsn string, table string, events []core.Event) error {
 	return nil
 }
 
-func stashToAudit(dsn string, table string, trail []db.AuditRecord) error {
-	if dbi, err := fromDSN(dsn); err != nil {
+// stashToAudit appends the records to the audit trail table, with the run ID of the command invocation.
+func (cmd command) stashToAudit(trail []db.AuditRecord) error {
+	for i := range trail {
+		if trail[i].RunID == "" {
+			trail[i].RunID = log.Run(cmd.runID).ID()
+		}
+	}
+
+	if dbi, err := fromDSN(cmd.dsn, cmd.tls); err != nil {
 		return err
-	} else if N, err := dbi.AuditTrail(table, trail); err != nil {
+	} else if N, err := dbi.AuditTrail(cmd.tables.Audit, trail); err != nil {
 		return err
 	} else if N == 1 {
-		infof("audit", "Added 1 record to audit trail")
+		cmd.infof("audit", "Added 1 record to audit trail")
 	} else {
-		infof("audit", "Added %v records to audit trail", N)
+		cmd.infof("audit", "Added %v records to audit trail", N)
 	}
 
 	return nil
 }
 
-func stashToLog(dsn string, table string, recordset []db.LogRecord) error {
-	if dbi, err := fromDSN(dsn); err != nil {
+// elapsed sets the operation duration for the operations log records.
+func elapsed(recordset []db.LogRecord, started time.Time) []db.LogRecord {
+	for i := range recordset {
+		recordset[i].Duration = time.Since(started)
+	}
+
+	return recordset
+}
+
+// stashToLog appends the records to the operations log table, with the run ID of the command invocation.
+func (cmd command) stashToLog(recordset []db.LogRecord) error {
+	for i := range recordset {
+		if recordset[i].RunID == "" {
+			recordset[i].RunID = log.Run(cmd.runID).ID()
+		}
+	}
+
+	if dbi, err := fromDSN(cmd.dsn, cmd.tls); err != nil {
 		return err
-	} else if N, err := dbi.Log(table, recordset); err != nil {
+	} else if N, err := dbi.Log(cmd.tables.Log, recordset); err != nil {
 		return err
 	} else if N == 1 {
-		infof("log", "Added 1 record to operations log")
+		cmd.infof("log", "Added 1 record to operations log")
 	} else {
-		infof("log", "Added %v records to operations log", N)
+		cmd.infof("log", "Added %v records to operations log", N)
 	}
 
 	return nil
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+)
+
+// difference is a single field that differs between the DB and controller records for a card.
+type difference struct {
+	Field      string `json:"field"`
+	DB         string `json:"db"`
+	Controller string `json:"controller"`
+}
+
+// diffDetails holds the field differences for the incorrect cards, indexed by controller and card number.
+type diffDetails map[uint32]map[uint32][]difference
+
+func (d difference) String() string {
+	return fmt.Sprintf("%-7v DB:%-12v controller:%v", d.Field, d.DB, d.Controller)
+}
+
+// fieldDiffs compares the DB record of each incorrect card with the card record on the controller.
+func fieldDiffs(current lib.ACL, diff lib.SystemDiff, withPIN bool) diffDetails {
+	m := diffDetails{}
+
+	for controller, v := range diff {
+		m[controller] = map[uint32][]difference{}
+
+		for _, card := range v.Updated {
+			if cards, ok := current[controller]; ok {
+				if actual, ok := cards[card.CardNumber]; ok {
+					m[controller][card.CardNumber] = differences(card, actual, withPIN)
+				}
+			}
+		}
+	}
+
+	return m
+}
+
+func differences(expected, actual core.Card, withPIN bool) []difference {
+	list := []difference{}
+
+	if !expected.From.Equals(actual.From) {
+		list = append(list, difference{"From", fmt.Sprintf("%v", expected.From), fmt.Sprintf("%v", actual.From)})
+	}
+
+	if !expected.To.Equals(actual.To) {
+		list = append(list, difference{"To", fmt.Sprintf("%v", expected.To), fmt.Sprintf("%v", actual.To)})
+	}
+
+	for _, door := range []uint8{1, 2, 3, 4} {
+		if expected.Doors[door] != actual.Doors[door] {
+			list = append(list, difference{fmt.Sprintf("Door %v", door), permission(expected.Doors[door]), permission(actual.Doors[door])})
+		}
+	}
+
+	if withPIN && expected.PIN != actual.PIN {
+		list = append(list, difference{"PIN", fmt.Sprintf("%v", expected.PIN), fmt.Sprintf("%v", actual.PIN)})
+	}
+
+	return list
+}
+
+// summarise formats a list of field differences compactly as field:DB/controller for the audit trail.
+func summarise(list []difference) string {
+	fields := []string{}
+	for _, d := range list {
+		fields = append(fields, fmt.Sprintf("%v:%v/%v", strings.ReplaceAll(d.Field, " ", ""), d.DB, d.Controller))
+	}
+
+	return strings.Join(fields, " ")
+}
+
+func permission(access uint8) string {
+	switch access {
+	case 0:
+		return "N"
+	case 1:
+		return "Y"
+	default:
+		return fmt.Sprintf("%v", access)
+	}
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	core "github.com/uhppoted/uhppote-core/types"
+)
+
+func TestDifferences(t *testing.T) {
+	expected := reportCard(10058400, 1, 29, 7531)
+
+	tests := []struct {
+		name     string
+		actual   core.Card
+		withPIN  bool
+		expected []difference
+	}{
+		{
+			name:     "same",
+			actual:   reportCard(10058400, 1, 29, 7531),
+			withPIN:  true,
+			expected: []difference{},
+		},
+		{
+			name:     "doors and PIN",
+			actual:   reportCard(10058400, 0, 1, 1357),
+			withPIN:  true,
+			expected: []difference{{"Door 1", "Y", "N"}, {"Door 2", "29", "Y"}, {"PIN", "7531", "1357"}},
+		},
+		{
+			name:     "PIN without --with-pin",
+			actual:   reportCard(10058400, 1, 29, 1357),
+			withPIN:  false,
+			expected: []difference{},
+		},
+	}
+
+	for _, test := range tests {
+		if list := differences(expected, test.actual, test.withPIN); !reflect.DeepEqual(list, test.expected) {
+			t.Errorf("%v: incorrect differences\n   expected:%v\n   got:     %v", test.name, test.expected, list)
+		}
+	}
+}
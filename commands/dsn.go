@@ -0,0 +1,211 @@
+package commands
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+// DSN_ENV is the environment variable used for the DSN if the DSN is not specified on the command line.
+const DSN_ENV = "UHPPOTED_APP_DB_DSN"
+
+var placeholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolve applies the --profile profile and resolves the DSN and TLS settings. A command that has already
+// been resolved (e.g. a run triggered by 'serve' with the server DSN) is not resolved again.
+func (cmd *command) resolve() error {
+	if cmd.resolved {
+		return nil
+	}
+
+	if err := cmd.applyProfile(); err != nil {
+		return err
+	} else if dsn, err := cmd.resolveDSN(); err != nil {
+		return err
+	} else if tls, err := cmd.resolveTLS(); err != nil {
+		return err
+	} else {
+		cmd.dsn = dsn
+		cmd.tls = tls
+		cmd.resolved = true
+	}
+
+	return nil
+}
+
+// resolveDSN returns the DSN with any placeholders replaced by the secret values so that credentials
+// need not appear on the command line. The DSN is taken from (in order of precedence):
+//
+//   - the --dsn command line argument, which can be 'env:<variable>' or 'file:<path>'
+//   - the UHPPOTED_APP_DB_DSN environment variable
+//   - db.dsn in uhppoted.conf
+//   - the file specified by db.dsn-file in uhppoted.conf
+//
+// ${NAME} placeholders are replaced with the value of the NAME environment variable or the output of the
+// db.secret.NAME command in uhppoted.conf.
+func (cmd command) resolveDSN() (string, error) {
+	s, err := loadSettings(cmd.config)
+	if err != nil {
+		return "", err
+	}
+
+	dsn := strings.TrimSpace(cmd.dsn)
+
+	if dsn == "" {
+		dsn = strings.TrimSpace(os.Getenv(DSN_ENV))
+	}
+
+	if dsn == "" {
+		dsn = strings.TrimSpace(s.DB.DSN)
+	}
+
+	if dsn == "" && strings.TrimSpace(s.DB.DSNFile) != "" {
+		dsn = "file:" + strings.TrimSpace(s.DB.DSNFile)
+	}
+
+	if name, ok := strings.CutPrefix(dsn, "env:"); ok {
+		if dsn = strings.TrimSpace(os.Getenv(name)); dsn == "" {
+			return "", fmt.Errorf("DSN environment variable %v is not set", name)
+		}
+	} else if file, ok := strings.CutPrefix(dsn, "file:"); ok {
+		if dsn, err = readSecret(file); err != nil {
+			return "", err
+		}
+	}
+
+	if dsn == "" {
+		return "", fmt.Errorf("invalid database DSN")
+	}
+
+	var errs []error
+
+	dsn = replace(dsn, func(v string, offset int) string {
+		name := placeholder.FindStringSubmatch(v)[1]
+
+		if secret, ok := os.LookupEnv(name); ok {
+			return escape(dsn, offset, secret)
+		} else if command := strings.TrimSpace(s.DB.Secrets[name]); command != "" {
+			if secret, err := runSecret(command); err != nil {
+				errs = append(errs, fmt.Errorf("error retrieving DSN secret %v (%v)", name, err))
+			} else {
+				return escape(dsn, offset, secret)
+			}
+		} else {
+			errs = append(errs, fmt.Errorf("unresolved DSN placeholder %v", v))
+		}
+
+		return v
+	})
+
+	if len(errs) > 0 {
+		return "", errs[0]
+	}
+
+	return dsn, nil
+}
+
+// resolveTLS returns the TLS settings for the DB connections. The db.tls settings in uhppoted.conf
+// are used for any TLS settings not defined by the --profile profile.
+func (cmd command) resolveTLS() (db.TLS, error) {
+	s, err := loadSettings(cmd.config)
+	if err != nil {
+		return db.TLS{}, err
+	}
+
+	setting := func(v string, dflt string) string {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+
+		return strings.TrimSpace(dflt)
+	}
+
+	tls := db.TLS{
+		Mode:        setting(cmd.tls.Mode, s.DB.TLS.Mode),
+		CA:          setting(cmd.tls.CA, s.DB.TLS.CA),
+		Certificate: setting(cmd.tls.Certificate, s.DB.TLS.Certificate),
+		Key:         setting(cmd.tls.Key, s.DB.TLS.Key),
+		ServerName:  setting(cmd.tls.ServerName, s.DB.TLS.ServerName),
+	}
+
+	if err := tls.Validate(); err != nil {
+		return db.TLS{}, err
+	}
+
+	return tls, nil
+}
+
+// replace replaces the ${NAME} placeholders in the DSN with the value returned by the function for the
+// placeholder and the offset of the placeholder in the DSN.
+func replace(dsn string, f func(v string, offset int) string) string {
+	var b strings.Builder
+
+	last := 0
+	for _, match := range placeholder.FindAllStringIndex(dsn, -1) {
+		b.WriteString(dsn[last:match[0]])
+		b.WriteString(f(dsn[match[0]:match[1]], match[0]))
+		last = match[1]
+	}
+
+	b.WriteString(dsn[last:])
+
+	return b.String()
+}
+
+// escape returns a secret escaped for the DSN format at the placeholder offset, so that secrets with e.g.
+// '@', '/', '?', '%' or ';' characters do not break the DSN:
+//
+//   - postgresql:// and sqlserver:// DSNs are URLs and the secret is percent-encoded
+//   - mysql:// DSNs are only percent-encoded in the DSN parameters, because the MySQL driver takes the
+//     user and password literally (up to the last '@' before the database name)
+//   - sqlite3:// DSNs are file paths and the secret is not escaped
+func escape(dsn string, offset int, secret string) string {
+	encoded := strings.ReplaceAll(url.QueryEscape(secret), "+", "%20")
+
+	switch {
+	case strings.HasPrefix(dsn, "postgresql://"), strings.HasPrefix(dsn, "sqlserver://"):
+		return encoded
+
+	case strings.HasPrefix(dsn, "mysql://"):
+		if ix := strings.Index(dsn, "?"); ix >= 0 && offset > ix {
+			return encoded
+		}
+
+		return secret
+
+	default:
+		return secret
+	}
+}
+
+// readSecret returns the contents of a secrets file (DSN file, PIN key, cache key, etc.), which is required
+// to not be accessible by other users.
+func readSecret(file string) (string, error) {
+	if info, err := os.Stat(file); err != nil {
+		return "", err
+	} else if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("secrets file %v is accessible by other users (%v)", file, info.Mode().Perm())
+	} else if bytes, err := os.ReadFile(file); err != nil {
+		return "", err
+	} else {
+		return strings.TrimSpace(string(bytes)), nil
+	}
+}
+
+// runSecret executes a command (e.g. 'pass show uhppoted/db') that prints a secret. The command is
+// executed directly and not via a shell.
+func runSecret(command string) (string, error) {
+	args := strings.Fields(command)
+
+	if stdout, err := exec.Command(args[0], args[1:]...).Output(); err != nil {
+		return "", err
+	} else {
+		return strings.TrimSpace(string(stdout)), nil
+	}
+}
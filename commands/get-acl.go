@@ -18,7 +18,7 @@ var GetACLCmd = GetACL{
 	command: command{
 		name:        "get-acl",
 		description: "Retrieves an access control list from a database and (optionally) saves it to a file",
-		usage:       "[--with-pin] --dsn <DSN> [--table:ACL <table>] [-table:log <table>] [--file <file>]",
+		usage:       "[--with-pin [--pin-key <file>]] [--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:groups <table> --table:members <table>] [-table:log <table>] [--file <file>]",
 
 		dsn: "",
 		tables: tables{
@@ -39,7 +39,7 @@ type GetACL struct {
 
 func (cmd *GetACL) Help() {
 	fmt.Println()
-	fmt.Printf("  Usage: %s [--debug] [--config <file>] get-acl [--with-pin] --dsn <DSN> [--table:ACL <table>] [-table:log <table>] [--file <file>]\n", APP)
+	fmt.Printf("  Usage: %s [--debug] [--config <file>] get-acl [--with-pin [--pin-key <file>]] [--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:groups <table> --table:members <table>] [-table:log <table>] [--file <file>]\n", APP)
 	fmt.Println()
 	fmt.Println("  Retrieves an access control list from a database and optionally saves the ACL to a TSV file")
 	fmt.Println()
@@ -61,7 +61,14 @@ func (cmd *GetACL) FlagSet() *flag.FlagSet {
 	flagset.StringVar(&cmd.tables.Log, "table:log", cmd.tables.Log, "Operations log table name. Defaults to ''")
 	flagset.StringVar(&cmd.file, "file", cmd.file, "Optional TSV filepath. Defaults to stdout")
 	flagset.BoolVar(&cmd.withPIN, "with-pin", cmd.withPIN, "Include card keypad PIN code in retrieved ACL information")
+	flagset.StringVar(&cmd.pinKey, "pin-key", cmd.pinKey, "File with the AES-256 key used to encrypt the card keypad PINs stored in the DB")
+	flagset.StringVar(&cmd.tables.Groups, "table:groups", cmd.tables.Groups, "Optional access groups table name. Defaults to ''")
+	flagset.StringVar(&cmd.tables.Members, "table:members", cmd.tables.Members, "Optional access group members table name. Defaults to ''")
+	flagset.StringVar(&cmd.columns, "columns", cmd.columns, "Optional file with the ACL table column mapping. Defaults to the mapping in the configuration file")
 	flagset.StringVar(&cmd.lockfile, "lockfile", cmd.lockfile, "Filepath for lock file. Defaults to <tmp>/uhppoted-app-db.lock")
+	flagset.StringVar(&cmd.profile, "profile", cmd.profile, "Optional named profile from the configuration file with the DSN, tables and options")
+
+	cmd.flagset = flagset
 
 	return flagset
 }
@@ -69,12 +76,14 @@ func (cmd *GetACL) FlagSet() *flag.FlagSet {
 func (cmd *GetACL) Execute(args ...any) error {
 	options := args[0].(*Options)
 
+	cmd.started = time.Now()
+
 	cmd.config = options.Config
 	cmd.debug = options.Debug
 
 	// ... check parameters
-	if strings.TrimSpace(cmd.dsn) == "" {
-		return fmt.Errorf("invalid database DSN")
+	if err := cmd.resolve(); err != nil {
+		return err
 	}
 
 	if strings.TrimSpace(cmd.tables.ACL) == "" {
@@ -86,7 +95,7 @@ func (cmd *GetACL) Execute(args ...any) error {
 		return err
 	} else {
 		defer func() {
-			infof("get-acl", "removing lockfile")
+			cmd.infof("get-acl", "removing lockfile")
 			kraken.Release()
 		}()
 	}
@@ -97,7 +106,19 @@ func (cmd *GetACL) Execute(args ...any) error {
 		return fmt.Errorf("could not load configuration (%v)", err)
 	}
 
-	_, devices := getDevices(conf, cmd.debug)
+	_, devices := getDevices(conf, cmd.timeout, cmd.debug)
+
+	// ... get ACL table column mapping
+	columns, err := cmd.getColumns()
+	if err != nil {
+		return err
+	}
+
+	// ... get PIN key
+	key, err := cmd.loadPINKey()
+	if err != nil {
+		return err
+	}
 
 	// ... retrieve ACL from DB
 	f := func(table lib.Table, devices []uhppote.Device) (*lib.ACL, []error, error) {
@@ -108,15 +129,25 @@ func (cmd *GetACL) Execute(args ...any) error {
 		}
 	}
 
-	if table, err := getACL(cmd.dsn, cmd.tables.ACL, cmd.withPIN); err != nil {
+	if table, err := getACL(cmd.dsn, cmd.tls, cmd.tables.ACL, columns, cmd.withPIN); err != nil {
+		return err
+	} else if err := decryptPINs(&table, key); err != nil {
+		return err
+	} else if table, err := expand(cmd.dsn, cmd.tls, cmd.tables, columns, table); err != nil {
 		return err
-	} else if acl, warnings, err := f(table, devices); err != nil {
+	} else if active, _, err := activeCards(table); err != nil {
+		return err
+	} else if active, scoped, err := scopes(active); err != nil {
+		return err
+	} else if acl, warnings, err := f(active, devices); err != nil {
 		return err
 	} else if acl == nil {
 		return fmt.Errorf("error creating ACL from DB table (%v)", acl)
 	} else {
+		restrict(*acl, scoped)
+
 		for _, w := range warnings {
-			warnf("get-acl", "%v", w.Error())
+			cmd.warnf("get-acl", "%v", w.Error())
 		}
 
 		// ... update operations log
@@ -126,10 +157,13 @@ func (cmd *GetACL) Execute(args ...any) error {
 					Timestamp: time.Now(),
 					Operation: "get-acl",
 					Detail:    fmt.Sprintf("records:%v", len(table.Records)),
+					Counters: map[string]int{
+						"records": len(table.Records),
+					},
 				},
 			}
 
-			if err := stashToLog(cmd.dsn, cmd.tables.Log, recordset); err != nil {
+			if err := cmd.stashToLog(elapsed(recordset, cmd.started)); err != nil {
 				return err
 			}
 		}
@@ -144,7 +178,7 @@ func (cmd *GetACL) Execute(args ...any) error {
 				return err
 			}
 
-			infof("get-acl", "ACL saved to %v", cmd.file)
+			cmd.infof("get-acl", "ACL saved to %v", cmd.file)
 			return nil
 		}
 
@@ -13,6 +13,7 @@ import (
 	"github.com/uhppoted/uhppote-core/uhppote"
 	"github.com/uhppoted/uhppoted-app-db/db"
 	"github.com/uhppoted/uhppoted-app-db/log"
+	"github.com/uhppoted/uhppoted-app-db/metrics"
 	"github.com/uhppoted/uhppoted-lib/config"
 )
 
@@ -75,6 +76,9 @@ func (cmd *GetEvents) FlagSet() *flag.FlagSet {
 	flagset.StringVar(&cmd.tables.Log, "table:log", cmd.tables.Log, "Operations log table name. Defaults to ''")
 	flagset.UintVar(&cmd.batchSize, "batch-size", cmd.batchSize, "Maximum events (per controller) to retrieve per invocation. Defaults to 128.")
 	flagset.StringVar(&cmd.lockfile, "lockfile", cmd.lockfile, "Filepath for lock file. Defaults to <tmp>/uhppoted-app-db.lock")
+	flagset.StringVar(&cmd.profile, "profile", cmd.profile, "Optional named profile from the configuration file with the DSN, tables and options")
+
+	cmd.flagset = flagset
 
 	return flagset
 }
@@ -82,14 +86,16 @@ func (cmd *GetEvents) FlagSet() *flag.FlagSet {
 func (cmd *GetEvents) Execute(args ...any) error {
 	options := args[0].(*Options)
 
+	cmd.started = time.Now()
+
 	cmd.config = options.Config
 	cmd.debug = options.Debug
 
 	log.SetDebug(options.Debug)
 
 	// ... check parameters
-	if strings.TrimSpace(cmd.dsn) == "" {
-		return fmt.Errorf("invalid database DSN")
+	if err := cmd.resolve(); err != nil {
+		return err
 	}
 
 	if strings.TrimSpace(cmd.tables.Events) == "" {
@@ -101,7 +107,7 @@ func (cmd *GetEvents) Execute(args ...any) error {
 		return err
 	} else {
 		defer func() {
-			infof("get-events", "removing lockfile")
+			cmd.infof("get-events", "removing lockfile")
 			kraken.Release()
 		}()
 	}
@@ -112,7 +118,7 @@ func (cmd *GetEvents) Execute(args ...any) error {
 		return fmt.Errorf("could not load configuration (%v)", err)
 	}
 
-	u, devices := getDevices(conf, false)
+	u, devices := getDevices(conf, cmd.timeout, false)
 
 	// ... retrieve events from controllers
 	events := []core.Event{}
@@ -122,7 +128,8 @@ func (cmd *GetEvents) Execute(args ...any) error {
 		controller := device.DeviceID
 
 		if list, err := cmd.getEvents(u, controller); err != nil {
-			warnf("get-events", "%v  %v", controller, err)
+			cmd.warnf("get-events", "%v  %v", controller, err)
+			metrics.Add(metrics.GetEventsErrors, controllerLabel(controller), 1)
 			errors = append(errors, err)
 		} else {
 			events = append(events, list...)
@@ -130,10 +137,12 @@ func (cmd *GetEvents) Execute(args ...any) error {
 	}
 
 	// ... store to DB
-	if err := putEvents(cmd.dsn, cmd.tables.Events, events); err != nil {
+	if err := putEvents(cmd.dsn, cmd.tls, cmd.tables.Events, events); err != nil {
 		return err
 	}
 
+	eventMetrics(events)
+
 	// ... add operations log
 	if cmd.tables.Log != "" {
 		recordset := []db.LogRecord{
@@ -141,10 +150,14 @@ func (cmd *GetEvents) Execute(args ...any) error {
 				Timestamp: time.Now(),
 				Operation: "get-events",
 				Detail:    fmt.Sprintf("records:%-4v errors:%-4v", len(events), len(errors)),
+				Counters: map[string]int{
+					"records": len(events),
+					"errors":  len(errors),
+				},
 			},
 		}
 
-		if err := stashToLog(cmd.dsn, cmd.tables.Log, recordset); err != nil {
+		if err := cmd.stashToLog(elapsed(recordset, cmd.started)); err != nil {
 			return err
 		}
 	}
@@ -153,12 +166,14 @@ func (cmd *GetEvents) Execute(args ...any) error {
 }
 
 func (cmd *GetEvents) getEvents(u uhppote.IUHPPOTE, controller uint32) ([]core.Event, error) {
-	infof("get-events", "%v  retrieving events", controller)
+	cmd.infof("get-events", "%v  retrieving events", controller)
 
 	if first, last, current, err := getEventIndices(u, controller); err != nil {
 		return nil, err
 	} else {
-		debugf("get-events", "%v  first:%-6v last:%-6v current:%-6v\n", controller, first, last, current)
+		cmd.debugf("get-events", "%v  first:%-6v last:%-6v current:%-6v\n", controller, first, last, current)
+
+		metrics.Set(metrics.LastEventIndex, controllerLabel(controller), float64(last))
 
 		var intervals []interval
 		if list, err := cmd.getMissing(GAPS, controller); err != nil {
@@ -178,9 +193,9 @@ func (cmd *GetEvents) getEvents(u uhppote.IUHPPOTE, controller uint32) ([]core.E
 
 		f := func(index uint32) {
 			if e, err := u.GetEvent(controller, index); err != nil {
-				warnf("get-events", "%v %v", controller, err)
+				cmd.warnf("get-events", "%v %v", controller, err)
 			} else if e == nil {
-				warnf("get-events", "%v  missing event %v", controller, index)
+				cmd.warnf("get-events", "%v  missing event %v", controller, index)
 				events = append(events, core.Event{
 					SerialNumber: core.SerialNumber(controller),
 					Index:        index,
@@ -229,7 +244,7 @@ func (cmd *GetEvents) getEvents(u uhppote.IUHPPOTE, controller uint32) ([]core.E
 
 		}
 
-		infof("get-events", "retrieved %v events", count)
+		cmd.infof("get-events", "retrieved %v events", count)
 
 		return events, nil
 	}
@@ -239,7 +254,7 @@ func (cmd *GetEvents) getMissing(gaps int, controller uint32) ([]interval, error
 	var events []uint32
 	var intervals []interval
 
-	if list, err := getEvents(cmd.dsn, cmd.tables.Events, controller); err != nil {
+	if list, err := getEvents(cmd.dsn, cmd.tls, cmd.tables.Events, controller); err != nil {
 		return nil, err
 	} else {
 		events = list
@@ -253,6 +268,9 @@ func (cmd *GetEvents) getMissing(gaps int, controller uint32) ([]interval, error
 	if N := len(events); N > 0 {
 		first = events[0]
 		last = events[N-1]
+
+		missing := int(last-first) + 1 - len(slices.Compact(slices.Clone(events)))
+		metrics.Set(metrics.EventGaps, controllerLabel(controller), float64(missing))
 	}
 
 	intervals = append(intervals, interval{from: last + 1, to: math.MaxUint32})
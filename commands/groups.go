@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+// expand merges the door permissions of the access groups to which a card belongs into the card's
+// ACL table row. Only group memberships that are valid for the current date are included and if a
+// card is a member of more than one group, the most permissive access for each door is used.
+func expand(dsn string, tls db.TLS, tables tables, columns db.Columns, table lib.Table) (lib.Table, error) {
+	if tables.Groups == "" || tables.Members == "" {
+		return table, nil
+	}
+
+	groups, err := getGroups(dsn, tls, tables.Groups, columns)
+	if err != nil {
+		return table, err
+	}
+
+	members, err := getGroupMembers(dsn, tls, tables.Members)
+	if err != nil {
+		return table, err
+	}
+
+	return expandGroups(table, groups, members, core.Date(time.Now()))
+}
+
+func expandGroups(table lib.Table, groups []db.Group, members []db.GroupMember, today core.Date) (lib.Table, error) {
+	header := append([]string{}, table.Header...)
+	records := [][]string{}
+	index := map[string]int{}
+
+	for i, h := range header {
+		index[normalise(h)] = i
+	}
+
+	cardnumber, ok := index["cardnumber"]
+	if !ok {
+		return table, fmt.Errorf("missing 'card number' column")
+	}
+
+	// ... add group doors that are not in the ACL table
+	grouplist := map[string]db.Group{}
+	for _, g := range groups {
+		grouplist[normalise(g.Name)] = g
+
+		for door := range g.Doors {
+			if _, ok := index[normalise(door)]; !ok {
+				index[normalise(door)] = len(header)
+				header = append(header, door)
+			}
+		}
+	}
+
+	for _, record := range table.Records {
+		row := append([]string{}, record...)
+		for len(row) < len(header) {
+			row = append(row, "N")
+		}
+
+		records = append(records, row)
+	}
+
+	// ... merge group permissions
+	cards := map[string][]string{}
+	for _, row := range records {
+		cards[row[cardnumber]] = row
+	}
+
+	for _, m := range members {
+		card := fmt.Sprintf("%v", m.CardNumber)
+
+		if row, ok := cards[card]; !ok {
+			warnf("groups", "card %v in group '%v' is not in the ACL table", m.CardNumber, m.Group)
+		} else if group, ok := grouplist[normalise(m.Group)]; !ok {
+			warnf("groups", "card %v is a member of unknown group '%v'", m.CardNumber, m.Group)
+		} else if !m.StartDate.IsZero() && today.Before(m.StartDate) {
+			debugf("groups", "card %v membership of group '%v' starts on %v", m.CardNumber, m.Group, m.StartDate)
+		} else if !m.EndDate.IsZero() && today.After(m.EndDate) {
+			debugf("groups", "card %v membership of group '%v' expired on %v", m.CardNumber, m.Group, m.EndDate)
+		} else {
+			for door, permission := range group.Doors {
+				ix := index[normalise(door)]
+				row[ix] = permissive(row[ix], permission)
+			}
+		}
+	}
+
+	return lib.Table{
+		Header:  header,
+		Records: records,
+	}, nil
+}
+
+// permissive returns the more permissive of two door permissions, where 'Y' is more permissive than
+// a time profile which is more permissive than 'N'.
+func permissive(p, q string) string {
+	rank := func(v string) int {
+		if strings.ToUpper(v) == "Y" {
+			return 2
+		} else if profile, err := strconv.ParseUint(v, 10, 8); err == nil && profile > 1 && profile < 255 {
+			return 1
+		} else {
+			return 0
+		}
+	}
+
+	if rank(q) > rank(p) {
+		return q
+	}
+
+	return p
+}
+
+func normalise(v string) string {
+	return strings.ToLower(strings.ReplaceAll(v, " ", ""))
+}
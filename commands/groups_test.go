@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestExpandGroups(t *testing.T) {
+	table := lib.Table{
+		Header: []string{"Card Number", "From", "To", "Great Hall"},
+		Records: [][]string{
+			{"10058400", "2025-01-01", "2025-12-31", "N"},
+			{"10058401", "2025-01-01", "2025-12-31", "N"},
+			{"10058402", "2025-01-01", "2025-12-31", "N"},
+		},
+	}
+
+	groups := []db.Group{
+		{Name: "Students", Doors: map[string]string{"Great Hall": "29"}},
+		{Name: "Staff", Doors: map[string]string{"Great Hall": "Y"}},
+		{Name: "Kitchen", Doors: map[string]string{"Kitchen": "Y"}},
+	}
+
+	members := []db.GroupMember{
+		{CardNumber: 10058400, Group: "students"},
+		{CardNumber: 10058400, Group: "Staff"},
+		{CardNumber: 10058401, Group: "Students"},
+		{CardNumber: 10058401, Group: "Kitchen", EndDate: core.MustParseDate("2025-06-14")},
+		{CardNumber: 10058402, Group: "Kitchen"},
+	}
+
+	expected := lib.Table{
+		Header: []string{"Card Number", "From", "To", "Great Hall", "Kitchen"},
+		Records: [][]string{
+			{"10058400", "2025-01-01", "2025-12-31", "Y", "N"},
+			{"10058401", "2025-01-01", "2025-12-31", "29", "N"},
+			{"10058402", "2025-01-01", "2025-12-31", "N", "Y"},
+		},
+	}
+
+	if expanded, err := expandGroups(table, groups, members, core.MustParseDate("2025-06-15")); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if !reflect.DeepEqual(expanded, expected) {
+		t.Errorf("incorrect ACL table\n   expected:%q\n   got:     %q", expected, expanded)
+	}
+}
@@ -0,0 +1,326 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/uhppoted/uhppoted-lib/config"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+var HistoryACLCmd = HistoryACL{
+	command: command{
+		name:        "history-acl",
+		description: "Displays the timeline of DB ACL changes and controller updates for a card",
+		usage:       "--dsn <DSN> --card <card number> [--table:history <table>] [--table:audit <table>] [--format text|json]",
+
+		dsn: "",
+		tables: tables{
+			History: "ACLHistory",
+			Audit:   "Audit",
+		},
+		lockfile: "",
+		config:   config.DefaultConfig,
+		debug:    false,
+	},
+	format: "text",
+}
+
+type HistoryACL struct {
+	command
+	card   uint
+	format string
+}
+
+type event struct {
+	Timestamp  string `json:"timestamp"`
+	Source     string `json:"source"`
+	Operation  string `json:"operation"`
+	Controller uint32 `json:"controller,omitempty"`
+	User       string `json:"user,omitempty"`
+	Detail     string `json:"detail,omitempty"`
+
+	before map[string]string
+	after  map[string]string
+}
+
+func (cmd *HistoryACL) Help() {
+	fmt.Println()
+	fmt.Printf("  Usage: %s [--debug] [--config <file>] history-acl --dsn <DSN> --card <card number> [--table:history <table>] [--table:audit <table>] [--format text|json]\n", APP)
+	fmt.Println()
+	fmt.Println("  Displays the changes to a card in the DB ACL table (from the ACL history table maintained by the")
+	fmt.Println("  optional change capture triggers) merged with the controller updates in the audit trail, as a single")
+	fmt.Println("  timeline")
+	fmt.Println()
+
+	helpOptions(cmd.FlagSet())
+
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println(`    uhppote-app-db history-acl --dsn "sqlite3://./db/ACL.db" --card 10058400`)
+	fmt.Println(`    uhppote-app-db history-acl --dsn "sqlite3://./db/ACL.db" --card 10058400 --table:history ACLHistory --table:audit Audit --format json`)
+	fmt.Println()
+}
+
+func (cmd *HistoryACL) FlagSet() *flag.FlagSet {
+	flagset := flag.NewFlagSet("history-acl", flag.ExitOnError)
+
+	flagset.StringVar(&cmd.dsn, "dsn", cmd.dsn, "DSN for database")
+	flagset.UintVar(&cmd.card, "card", cmd.card, "Card number")
+	flagset.StringVar(&cmd.tables.History, "table:history", cmd.tables.History, "ACL history table name. Defaults to ACLHistory")
+	flagset.StringVar(&cmd.tables.Audit, "table:audit", cmd.tables.Audit, "Audit trail table name. Defaults to Audit")
+	flagset.StringVar(&cmd.format, "format", cmd.format, "Output format (text or json). Defaults to text")
+	flagset.StringVar(&cmd.lockfile, "lockfile", cmd.lockfile, "Filepath for lock file. Defaults to <tmp>/uhppoted-app-db.lock")
+	flagset.StringVar(&cmd.profile, "profile", cmd.profile, "Optional named profile from the configuration file with the DSN, tables and options")
+
+	cmd.flagset = flagset
+
+	return flagset
+}
+
+func (cmd *HistoryACL) Execute(args ...any) error {
+	options := args[0].(*Options)
+
+	cmd.config = options.Config
+	cmd.debug = options.Debug
+
+	// ... check parameters
+	if err := cmd.resolve(); err != nil {
+		return err
+	}
+
+	if cmd.card == 0 {
+		return fmt.Errorf("invalid card number")
+	}
+
+	if strings.TrimSpace(cmd.tables.History) == "" && strings.TrimSpace(cmd.tables.Audit) == "" {
+		return fmt.Errorf("at least one of the history and audit tables is required")
+	}
+
+	if cmd.format != "text" && cmd.format != "json" {
+		return fmt.Errorf("invalid format (%v)", cmd.format)
+	}
+
+	// ... locked?
+	if kraken, err := lock(cmd.lockfile); err != nil {
+		return err
+	} else {
+		defer func() {
+			cmd.infof("history-acl", "removing lockfile")
+			kraken.Release()
+		}()
+	}
+
+	// ... get DB and controller history
+	filter := db.Filter{
+		Where: map[string]any{
+			"CardNumber": uint32(cmd.card),
+		},
+	}
+
+	timeline := []event{}
+
+	if cmd.tables.History != "" {
+		if records, err := query(cmd.dsn, cmd.tls, cmd.tables.History, filter); err != nil {
+			return err
+		} else {
+			timeline = append(timeline, merge(history2events(records))...)
+		}
+	}
+
+	if cmd.tables.Audit != "" {
+		if records, err := query(cmd.dsn, cmd.tls, cmd.tables.Audit, filter); err != nil {
+			return err
+		} else {
+			timeline = append(timeline, audit2events(records)...)
+		}
+	}
+
+	slices.SortStableFunc(timeline, func(p, q event) int {
+		return strings.Compare(p.Timestamp, q.Timestamp)
+	})
+
+	// ... display
+	if cmd.format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(timeline); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+		for _, e := range timeline {
+			controller := ""
+			if e.Controller != 0 {
+				controller = fmt.Sprintf("%v", e.Controller)
+			}
+
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", e.Timestamp, e.Source, e.Operation, controller, e.User, e.Detail)
+		}
+
+		w.Flush()
+	}
+
+	return nil
+}
+
+func history2events(records []db.Record) []event {
+	events := []event{}
+
+	for _, r := range slices.Backward(records) {
+		e := event{
+			Timestamp: field(r, "Timestamp"),
+			Source:    "DB",
+			Operation: field(r, "Operation"),
+			User:      field(r, "UserName"),
+			before:    image(field(r, "OldRow")),
+			after:     image(field(r, "NewRow")),
+		}
+
+		events = append(events, e)
+	}
+
+	return events
+}
+
+// merge combines the DELETE+INSERT pairs generated by ACL table rewrites into a single UPDATE and drops
+// the updates that did not change the card. The pairs are matched in either order since the ordering of
+// records with the same timestamp is not defined.
+func merge(events []event) []event {
+	merged := []event{}
+
+	for i := 0; i < len(events); i++ {
+		e := events[i]
+
+		if i+1 < len(events) && events[i+1].Timestamp == e.Timestamp {
+			next := events[i+1]
+
+			if e.Operation == "DELETE" && next.Operation == "INSERT" {
+				e.Operation = "UPDATE"
+				e.after = next.after
+				i++
+			} else if e.Operation == "INSERT" && next.Operation == "DELETE" {
+				e.Operation = "UPDATE"
+				e.before = next.before
+				i++
+			}
+		}
+
+		if e.Operation == "UPDATE" && maps.Equal(e.before, e.after) {
+			continue
+		}
+
+		e.Detail = changes(e.before, e.after)
+		merged = append(merged, e)
+	}
+
+	return merged
+}
+
+func audit2events(records []db.Record) []event {
+	events := []event{}
+
+	for _, r := range slices.Backward(records) {
+		e := event{
+			Timestamp: field(r, "Timestamp"),
+			Source:    "controller",
+			Operation: field(r, "Operation"),
+			Detail:    strings.TrimSpace(fmt.Sprintf("%v %v", field(r, "Status"), field(r, "Card"))),
+		}
+
+		if v, err := strconv.ParseUint(field(r, "Controller"), 10, 32); err == nil {
+			e.Controller = uint32(v)
+		}
+
+		events = append(events, e)
+	}
+
+	return events
+}
+
+// changes summarises the differences between the before and after images of an ACL row.
+func changes(before, after map[string]string) string {
+	list := []string{}
+
+	switch {
+	case before == nil && after == nil:
+		return ""
+
+	case before == nil:
+		for _, k := range slices.Sorted(maps.Keys(after)) {
+			list = append(list, fmt.Sprintf("%v:%v", k, after[k]))
+		}
+
+	case after == nil:
+		return "deleted"
+
+	default:
+		keys := slices.Sorted(maps.Keys(before))
+		for _, k := range slices.Sorted(maps.Keys(after)) {
+			if _, ok := before[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+
+		for _, k := range keys {
+			if before[k] != after[k] {
+				list = append(list, fmt.Sprintf("%v:%v->%v", k, before[k], after[k]))
+			}
+		}
+	}
+
+	return strings.Join(list, " ")
+}
+
+// image unpacks a JSON row image, normalising the values to strings. Numbers are decoded as json.Number so
+// that e.g. card numbers are not formatted as floats.
+func image(v string) map[string]string {
+	if v == "" {
+		return nil
+	}
+
+	var row map[string]any
+
+	decoder := json.NewDecoder(strings.NewReader(v))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&row); err != nil {
+		warnf("history-acl", "invalid ACL row image (%v)", err)
+		return nil
+	}
+
+	m := map[string]string{}
+	for k, v := range row {
+		if v == nil {
+			m[k] = ""
+		} else {
+			m[k] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	return m
+}
+
+// field returns the (case-insensitive) record value as a string, since PostgreSQL folds unquoted column
+// names to lowercase.
+func field(r db.Record, column string) string {
+	for k, v := range r {
+		if strings.EqualFold(k, column) && v != nil {
+			return fmt.Sprintf("%v", v)
+		}
+	}
+
+	return ""
+}
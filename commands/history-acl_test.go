@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	row := func(door string) map[string]string {
+		return map[string]string{"CardNumber": "10058400", "GreatHall": door}
+	}
+
+	tests := []struct {
+		name     string
+		events   []event
+		expected []event
+	}{
+		{
+			name: "DELETE+INSERT",
+			events: []event{
+				{Timestamp: "12:00:01", Operation: "DELETE", before: row("Y")},
+				{Timestamp: "12:00:01", Operation: "INSERT", after: row("N")},
+			},
+			expected: []event{
+				{Timestamp: "12:00:01", Operation: "UPDATE", Detail: "GreatHall:Y->N", before: row("Y"), after: row("N")},
+			},
+		},
+		{
+			name: "unchanged rewrite",
+			events: []event{
+				{Timestamp: "12:00:01", Operation: "DELETE", before: row("Y")},
+				{Timestamp: "12:00:01", Operation: "INSERT", after: row("Y")},
+				{Timestamp: "12:00:02", Operation: "UPDATE", before: row("Y"), after: row("Y")},
+			},
+			expected: []event{},
+		},
+	}
+
+	for _, test := range tests {
+		if events := merge(test.events); !reflect.DeepEqual(events, test.expected) {
+			t.Errorf("%v: incorrect events\n   expected:%+v\n   got:     %+v", test.name, test.expected, events)
+		}
+	}
+}
+
+func TestImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		row      string
+		expected map[string]string
+	}{
+		{"row", `{"CardNumber":"10058400","GreatHall":true,"Kitchen":29,"Name":null}`, map[string]string{"CardNumber": "10058400", "GreatHall": "true", "Kitchen": "29", "Name": ""}},
+		{"numbers", `{"CardNumber":10058400,"PIN":7531,"Amount":1.5}`, map[string]string{"CardNumber": "10058400", "PIN": "7531", "Amount": "1.5"}},
+	}
+
+	for _, test := range tests {
+		if m := image(test.row); !reflect.DeepEqual(m, test.expected) {
+			t.Errorf("%v: incorrect row image - expected:%v, got:%v", test.name, test.expected, m)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	core "github.com/uhppoted/uhppote-core/types"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+type dateRange struct {
+	from core.Date
+	to   core.Date
+}
+
+// holidaysFor returns the holidays that apply to a controller i.e. the holidays without a controller and the
+// holidays for the controller, sorted by date. Duplicate dates are ignored.
+func holidaysFor(holidays []db.Holiday, controller uint32) []db.Holiday {
+	list := []db.Holiday{}
+
+	for _, h := range holidays {
+		if h.Controller == 0 || h.Controller == controller {
+			list = append(list, h)
+		}
+	}
+
+	slices.SortStableFunc(list, func(p, q db.Holiday) int {
+		return time.Time(p.Date).Compare(time.Time(q.Date))
+	})
+
+	return slices.CompactFunc(list, func(p, q db.Holiday) bool {
+		return p.Date.Equals(q.Date)
+	})
+}
+
+// expandHolidays excludes the holidays from the time profiles by splitting the date range of each time profile
+// around the holidays in the range. The first date range keeps the time profile ID and the remaining date ranges
+// are added as time profiles with unused IDs, each linked to the next and the last linked to the original linked
+// profile (if any). A time profile with a date range that is entirely holidays is disabled on every weekday.
+func expandHolidays(profiles []core.TimeProfile, holidays []db.Holiday) ([]core.TimeProfile, error) {
+	used := map[uint8]bool{}
+	for _, p := range profiles {
+		used[p.ID] = true
+	}
+
+	next := uint8(254)
+	unused := func() (uint8, bool) {
+		for ; next > 1; next-- {
+			if !used[next] {
+				used[next] = true
+				return next, true
+			}
+		}
+
+		return 0, false
+	}
+
+	expanded := []core.TimeProfile{}
+
+	for _, p := range profiles {
+		if !slices.ContainsFunc(holidays, func(h db.Holiday) bool { return !h.Date.Before(p.From) && !h.Date.After(p.To) }) {
+			expanded = append(expanded, p)
+			continue
+		}
+
+		ranges := split(p.From, p.To, holidays)
+		if len(ranges) == 0 {
+			disabled := p
+			disabled.Weekdays = core.Weekdays{}
+
+			expanded = append(expanded, disabled)
+			continue
+		}
+
+		ids := []uint8{p.ID}
+		for range ranges[1:] {
+			if id, ok := unused(); !ok {
+				return nil, fmt.Errorf("insufficient unused time profile IDs for the holidays in time profile %v", p.ID)
+			} else {
+				ids = append(ids, id)
+			}
+		}
+
+		for i, r := range ranges {
+			profile := p
+			profile.ID = ids[i]
+			profile.From = r.from
+			profile.To = r.to
+
+			if i+1 < len(ranges) {
+				profile.LinkedProfileID = ids[i+1]
+			}
+
+			expanded = append(expanded, profile)
+		}
+	}
+
+	return expanded, nil
+}
+
+// split returns the date ranges between from and to (inclusive) that do not include any of the (sorted) holidays.
+func split(from, to core.Date, holidays []db.Holiday) []dateRange {
+	day := func(d core.Date, days int) core.Date {
+		return core.Date(time.Time(d).AddDate(0, 0, days))
+	}
+
+	ranges := []dateRange{}
+	start := from
+
+	for _, h := range holidays {
+		if h.Date.Before(start) || h.Date.After(to) {
+			continue
+		}
+
+		if h.Date.After(start) {
+			ranges = append(ranges, dateRange{start, day(h.Date, -1)})
+		}
+
+		start = day(h.Date, 1)
+	}
+
+	if !start.After(to) {
+		ranges = append(ranges, dateRange{start, to})
+	}
+
+	return ranges
+}
+
+// holidayTrail returns the audit trail records for the holidays added to and removed from a controller since the
+// last load-profiles, using the holiday records in the audit trail to reconstruct the previous controller holidays.
+func (cmd *LoadProfiles) holidayTrail(controller uint32, holidays []db.Holiday) ([]db.AuditRecord, error) {
+	filter := db.Filter{
+		Where: map[string]any{"Operation": "holiday", "Controller": controller},
+	}
+
+	records, err := query(cmd.dsn, cmd.tls, cmd.tables.Audit, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// ... replay audit trail (oldest record first)
+	previous := map[string]string{}
+
+	for _, r := range slices.Backward(records) {
+		if date, name, ok := holiday(field(r, "Card")); !ok {
+			continue
+		} else if status := field(r, "Status"); status == "added" {
+			previous[date] = name
+		} else if status == "removed" {
+			delete(previous, date)
+		}
+	}
+
+	now := time.Now()
+	trail := []db.AuditRecord{}
+	record := func(date, name, status string) db.AuditRecord {
+		return db.AuditRecord{
+			Timestamp:  now,
+			Operation:  "holiday",
+			Controller: controller,
+			Status:     status,
+			Card:       strings.TrimSpace(fmt.Sprintf("%v %v", date, name)),
+		}
+	}
+
+	current := map[string]bool{}
+	for _, h := range holidays {
+		date := h.Date.String()
+		current[date] = true
+
+		if _, ok := previous[date]; !ok {
+			trail = append(trail, record(date, h.Name, "added"))
+		}
+	}
+
+	for _, date := range slices.Sorted(maps.Keys(previous)) {
+		if !current[date] {
+			trail = append(trail, record(date, previous[date], "removed"))
+		}
+	}
+
+	return trail, nil
+}
+
+// holiday parses the '<date> <name>' holiday audit trail Card field.
+func holiday(card string) (string, string, bool) {
+	if len(card) < 10 {
+		return "", "", false
+	} else if _, err := core.ParseDate(card[:10]); err != nil {
+		return "", "", false
+	} else {
+		return card[:10], strings.TrimSpace(card[10:]), true
+	}
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	core "github.com/uhppoted/uhppote-core/types"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestExpandHolidays(t *testing.T) {
+	date := core.MustParseDate
+
+	type profile struct {
+		ID     uint8
+		From   string
+		To     string
+		Linked uint8
+	}
+
+	tests := []struct {
+		name     string
+		holidays []string
+		expected []profile
+	}{
+		{"no holidays", nil, []profile{{29, "2026-12-01", "2026-12-31", 30}, {254, "2026-01-01", "2026-12-31", 0}}},
+		{"outside range", []string{"2027-01-01"}, []profile{{29, "2026-12-01", "2026-12-31", 30}, {254, "2026-01-01", "2026-12-31", 0}}},
+		{
+			"split",
+			[]string{"2026-12-25", "2026-12-26"},
+			[]profile{
+				{29, "2026-12-01", "2026-12-24", 253},
+				{253, "2026-12-27", "2026-12-31", 30},
+				{254, "2026-01-01", "2026-12-24", 252},
+				{252, "2026-12-27", "2026-12-31", 0},
+			},
+		},
+		{
+			"first and last day",
+			[]string{"2026-12-01", "2026-12-31"},
+			[]profile{
+				{29, "2026-12-02", "2026-12-30", 30},
+				{254, "2026-01-01", "2026-11-30", 253},
+				{253, "2026-12-02", "2026-12-30", 0},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		profiles := []core.TimeProfile{
+			{ID: 29, From: date("2026-12-01"), To: date("2026-12-31"), LinkedProfileID: 30},
+			{ID: 254, From: date("2026-01-01"), To: date("2026-12-31")},
+		}
+
+		holidays := []db.Holiday{}
+		for _, h := range test.holidays {
+			holidays = append(holidays, db.Holiday{Date: date(h)})
+		}
+
+		expanded, err := expandHolidays(profiles, holidays)
+		if err != nil {
+			t.Fatalf("%v: unexpected error (%v)", test.name, err)
+		}
+
+		list := []profile{}
+		for _, p := range expanded {
+			list = append(list, profile{p.ID, p.From.String(), p.To.String(), p.LinkedProfileID})
+		}
+
+		if !reflect.DeepEqual(list, test.expected) {
+			t.Errorf("%v: incorrect time profiles\n   expected:%v\n   got:     %v", test.name, test.expected, list)
+		}
+	}
+}
+
+func TestHolidayTrail(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "ACL.db")
+
+	if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		for _, s := range []string{
+			`CREATE TABLE Audit (Timestamp TEXT, Operation TEXT, Controller INTEGER, CardNumber INTEGER, Status TEXT, Card TEXT);`,
+			`INSERT INTO Audit VALUES ('2026-01-01 12:00:00', 'holiday', 405419896, 0, 'added', '2026-12-25 Christmas Day');`,
+			`INSERT INTO Audit VALUES ('2026-01-01 12:00:00', 'holiday', 405419896, 0, 'added', '2026-12-26 Boxing Day');`,
+			`INSERT INTO Audit VALUES ('2026-02-01 12:00:00', 'holiday', 405419896, 0, 'removed', '2026-12-26 Boxing Day');`,
+			`INSERT INTO Audit VALUES ('2026-02-01 12:00:00', 'holiday', 405419896, 0, 'added', '2026-12-31 New Year''s Eve');`,
+			`INSERT INTO Audit VALUES ('2026-02-01 12:00:00', 'holiday', 303986753, 0, 'added', '2026-12-24 Christmas Eve');`,
+		} {
+			if _, err := dbc.Exec(s); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+
+		dbc.Close()
+	}
+
+	cmd := LoadProfilesCmd
+	cmd.dsn = "sqlite3://" + dsn
+	cmd.tables.Audit = "Audit"
+
+	holidays := []db.Holiday{
+		{Date: core.MustParseDate("2026-12-25"), Name: "Christmas Day"},
+		{Date: core.MustParseDate("2026-12-26"), Name: "Boxing Day"},
+	}
+
+	expected := [][]string{
+		{"added", "2026-12-26 Boxing Day"},
+		{"removed", "2026-12-31 New Year's Eve"},
+	}
+
+	trail, err := cmd.holidayTrail(405419896, holidays)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	list := [][]string{}
+	for _, r := range trail {
+		list = append(list, []string{r.Status, r.Card})
+	}
+
+	if !reflect.DeepEqual(list, expected) {
+		t.Errorf("incorrect holiday audit trail\n   expected:%q\n   got:     %q", expected, list)
+	}
+}
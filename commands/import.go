@@ -0,0 +1,424 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/uhppote"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+// rowError is a validation error for a single record in an imported ACL file. The row is the record
+// number in the file, starting at 1 for the first record after the header.
+type rowError struct {
+	Row        int
+	CardNumber string
+	Column     string
+	Err        string
+}
+
+// readTable reads an ACL table from a TSV, CSV, JSON or XLSX file. The format defaults to the file
+// extension, falling back to TSV.
+func readTable(file string, format string) (lib.Table, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".csv":
+			format = "csv"
+		case ".json":
+			format = "json"
+		case ".xlsx":
+			format = "xlsx"
+		default:
+			format = "tsv"
+		}
+	}
+
+	var records [][]string
+	var err error
+
+	switch strings.ToLower(format) {
+	case "tsv":
+		records, err = readDelimited(file, '\t')
+
+	case "csv":
+		records, err = readDelimited(file, ',')
+
+	case "json":
+		records, err = readJSON(file)
+
+	case "xlsx":
+		if records, err = readXLSX(file); err == nil {
+			records = fromSerialDates(records)
+		}
+
+	default:
+		return lib.Table{}, fmt.Errorf("invalid file format (%v)", format)
+	}
+
+	if err != nil {
+		return lib.Table{}, err
+	} else if len(records) == 0 {
+		return lib.Table{}, fmt.Errorf("%v file is empty", strings.ToUpper(format))
+	}
+
+	// ... header
+	header := make([]string, len(records[0]))
+
+	for i, v := range records[0] {
+		header[i] = strings.TrimSpace(v)
+	}
+
+	// ... records
+	rows := make([][]string, 0)
+
+	for _, record := range records[1:] {
+		row := make([]string, len(record))
+
+		for i, v := range record {
+			row[i] = strings.TrimSpace(v)
+		}
+
+		if strings.Join(row, "") != "" {
+			rows = append(rows, row)
+		}
+	}
+
+	return lib.Table{
+		Header:  header,
+		Records: rows,
+	}, nil
+}
+
+func readDelimited(file string, comma rune) ([][]string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+
+	return r.ReadAll()
+}
+
+// readJSON reads an ACL from a JSON array of objects e.g. [{"Card Number": 10058400, "From": "2025-01-01", ... }].
+// The header is the list of object keys, in the order they first occur in the file. Boolean values are
+// converted to Y/N.
+func readJSON(file string) ([][]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	decoder.UseNumber()
+
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('[') {
+		return nil, fmt.Errorf("invalid JSON ACL (expected array of records)")
+	}
+
+	header := []string{}
+	index := map[string]int{}
+	objects := []map[string]string{}
+
+	for decoder.More() {
+		object, err := readObject(decoder, func(key string) {
+			if _, ok := index[key]; !ok {
+				index[key] = len(header)
+				header = append(header, key)
+			}
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, object)
+	}
+
+	if _, err := decoder.Token(); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	records := [][]string{header}
+	for _, object := range objects {
+		record := make([]string, len(header))
+		for k, v := range object {
+			record[index[k]] = v
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func readObject(decoder *json.Decoder, key func(string)) (map[string]string, error) {
+	object := map[string]string{}
+
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('{') {
+		return nil, fmt.Errorf("invalid JSON ACL record (expected object)")
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		k, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid JSON ACL record key (%v)", token)
+		}
+
+		var v any
+		if err := decoder.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		switch value := v.(type) {
+		case nil:
+			object[k] = ""
+
+		case bool:
+			if value {
+				object[k] = "Y"
+			} else {
+				object[k] = "N"
+			}
+
+		case string, json.Number:
+			object[k] = fmt.Sprintf("%v", value)
+
+		default:
+			return nil, fmt.Errorf("invalid JSON ACL value for %v (%v)", k, v)
+		}
+
+		key(k)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// fromSerialDates converts the Excel serial day numbers in the 'from' and 'to' columns to dates.
+func fromSerialDates(records [][]string) [][]string {
+	if len(records) == 0 {
+		return records
+	}
+
+	epoch := time.Date(1899, time.December, 30, 0, 0, 0, 0, time.Local)
+
+	for i, h := range records[0] {
+		if k := normalise(h); k == "from" || k == "to" {
+			for _, record := range records[1:] {
+				if i < len(record) {
+					if days, err := strconv.Atoi(record[i]); err == nil && days > 0 {
+						record[i] = epoch.AddDate(0, 0, days).Format("2006-01-02")
+					}
+				}
+			}
+		}
+	}
+
+	return records
+}
+
+// validateACL checks the card numbers, dates, PINs and door columns of each record in an ACL table,
+// returning the valid records and a list of the row errors. The door columns are validated against
+// the doors configured in uhppoted.conf (if any). Returns an error if the header is invalid.
+func validateACL(table lib.Table, devices []uhppote.Device, columns db.Columns, withPIN bool) (lib.Table, []rowError, error) {
+	index := map[string]int{}
+	doors := []int{}
+	configured := map[string]bool{}
+
+	for _, device := range devices {
+		for _, door := range device.Doors {
+			if d := normalise(door); d != "" {
+				configured[d] = true
+			}
+		}
+	}
+
+	for i, h := range table.Header {
+		k := normalise(h)
+
+		if _, ok := index[k]; ok {
+			return table, nil, fmt.Errorf("duplicate column '%v'", h)
+		}
+
+		index[k] = i
+
+		switch k {
+		case "cardnumber", "from", "to", "pin", "name", "status", "controllers", normalise(columns.Status), normalise(columns.Controllers):
+
+		default:
+			if _, ok := columns.Column(h); !ok {
+				continue
+			} else if len(configured) > 0 && !configured[k] {
+				return table, nil, fmt.Errorf("no configured door matches '%v'", h)
+			} else {
+				doors = append(doors, i)
+			}
+		}
+	}
+
+	for _, k := range []string{"cardnumber", "from", "to"} {
+		if _, ok := index[k]; !ok {
+			return table, nil, fmt.Errorf("missing '%v' column", k)
+		}
+	}
+
+	profile := regexp.MustCompile("^[0-9]+$")
+	valid := [][]string{}
+	errors := []rowError{}
+	cards := map[uint64]int{}
+
+	for i, record := range table.Records {
+		row := i + 1
+		card := ""
+		failed := false
+
+		fail := func(column string, format string, args ...any) {
+			errors = append(errors, rowError{
+				Row:        row,
+				CardNumber: card,
+				Column:     column,
+				Err:        fmt.Sprintf(format, args...),
+			})
+
+			failed = true
+		}
+
+		if len(record) > len(table.Header) {
+			fail("", "record has %v fields (expected %v)", len(record), len(table.Header))
+			continue
+		}
+
+		for len(record) < len(table.Header) {
+			record = append(record, "")
+		}
+
+		card = record[index["cardnumber"]]
+
+		// ... card number
+		if v, err := strconv.ParseUint(card, 10, 32); err != nil || v == 0 {
+			fail(table.Header[index["cardnumber"]], "invalid card number '%v'", card)
+		} else if previous, ok := cards[v]; ok {
+			fail(table.Header[index["cardnumber"]], "duplicate card number (row %v)", previous)
+		} else {
+			cards[v] = row
+		}
+
+		// ... dates
+		from, err := time.ParseInLocation("2006-01-02", record[index["from"]], time.Local)
+		if err != nil {
+			fail(table.Header[index["from"]], "invalid 'from' date '%v'", record[index["from"]])
+		}
+
+		to, err := time.ParseInLocation("2006-01-02", record[index["to"]], time.Local)
+		if err != nil {
+			fail(table.Header[index["to"]], "invalid 'to' date '%v'", record[index["to"]])
+		}
+
+		if !from.IsZero() && !to.IsZero() && to.Before(from) {
+			fail(table.Header[index["to"]], "'to' date %v is before 'from' date %v", record[index["to"]], record[index["from"]])
+		}
+
+		// ... PIN
+		if ix, ok := index["pin"]; ok && withPIN {
+			if pin := record[ix]; pin != "" && !db.IsEncryptedPIN(pin) {
+				if v, err := strconv.ParseUint(pin, 10, 32); err != nil || v > db.MaxPIN {
+					fail(table.Header[ix], "invalid PIN '%v' (expected 0-999999)", pin)
+				}
+			}
+		}
+
+		// ... doors
+		for _, ix := range doors {
+			door := table.Header[ix]
+
+			switch v := strings.ToUpper(strings.TrimSpace(record[ix])); {
+			case v == "":
+				record[ix] = "N"
+
+			case v == "Y" || v == "N":
+				record[ix] = v
+
+			case profile.MatchString(v):
+				if p, err := strconv.Atoi(v); err != nil || p < 2 || p > 254 {
+					fail(door, "invalid time profile '%v' (expected 2-254)", v)
+				}
+
+			default:
+				fail(door, "invalid door permission '%v' (expected Y, N or a time profile)", record[ix])
+			}
+		}
+
+		if !failed {
+			valid = append(valid, record)
+		}
+	}
+
+	return lib.Table{
+		Header:  table.Header,
+		Records: valid,
+	}, errors, nil
+}
+
+// writeReport writes the row-level validation errors as a TSV file, or to stdout as a table if the file
+// is not specified.
+func writeReport(file string, errors []rowError) error {
+	header := []string{"ROW", "CARD", "COLUMN", "ERROR"}
+	records := [][]string{}
+
+	for _, e := range errors {
+		records = append(records, []string{fmt.Sprintf("%v", e.Row), e.CardNumber, e.Column, e.Err})
+	}
+
+	if file == "" {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+		fmt.Fprintf(w, "%v\t\n", strings.Join(header, "\t"))
+		for _, record := range records {
+			fmt.Fprintf(w, "%v\t\n", strings.Join(record, "\t"))
+		}
+
+		return w.Flush()
+	}
+
+	var b bytes.Buffer
+
+	w := csv.NewWriter(&b)
+	w.Comma = '\t'
+
+	if err := w.Write(header); err != nil {
+		return err
+	} else if err := w.WriteAll(records); err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, b.Bytes(), 0660)
+}
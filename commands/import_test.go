@@ -0,0 +1,288 @@
+package commands
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/uhppoted/uhppote-core/uhppote"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestValidateACL(t *testing.T) {
+	devices := []uhppote.Device{
+		{DeviceID: 405419896, Doors: []string{"Great Hall", "Gryffindor", "HufflePuff", "Ravenclaw"}},
+	}
+
+	header := []string{"Card Number", "PIN", "From", "To", "Great Hall", "Gryffindor"}
+
+	tests := []struct {
+		name    string
+		records [][]string
+		valid   [][]string
+		errors  []rowError
+	}{
+		{
+			name: "valid",
+			records: [][]string{
+				{"10058400", "7531", "2025-01-01", "2025-12-31", "Y", "n"},
+				{"10058401", "", "2025-01-01", "2025-12-31", "N", "29"},
+				{"10058402", "999999", "2025-01-01", "2025-01-01", "y", "Y"},
+			},
+			valid: [][]string{
+				{"10058400", "7531", "2025-01-01", "2025-12-31", "Y", "N"},
+				{"10058401", "", "2025-01-01", "2025-12-31", "N", "29"},
+				{"10058402", "999999", "2025-01-01", "2025-01-01", "Y", "Y"},
+			},
+			errors: []rowError{},
+		},
+		{
+			name: "card number",
+			records: [][]string{
+				{"0", "", "2025-01-01", "2025-12-31", "Y", "N"},
+				{"x", "", "2025-01-01", "2025-12-31", "Y", "N"},
+				{"10058400", "", "2025-01-01", "2025-12-31", "Y", "N"},
+				{"10058400", "", "2025-01-01", "2025-12-31", "Y", "N"},
+			},
+			valid: [][]string{
+				{"10058400", "", "2025-01-01", "2025-12-31", "Y", "N"},
+			},
+			errors: []rowError{
+				{Row: 1, CardNumber: "0", Column: "Card Number", Err: "invalid card number '0'"},
+				{Row: 2, CardNumber: "x", Column: "Card Number", Err: "invalid card number 'x'"},
+				{Row: 4, CardNumber: "10058400", Column: "Card Number", Err: "duplicate card number (row 3)"},
+			},
+		},
+		{
+			name: "PIN",
+			records: [][]string{
+				{"10058400", "1000000", "2025-01-01", "2025-12-31", "Y", "N"},
+				{"10058402", "12a4", "2025-01-01", "2025-12-31", "Y", "N"},
+			},
+			valid: [][]string{},
+			errors: []rowError{
+				{Row: 1, CardNumber: "10058400", Column: "PIN", Err: "invalid PIN '1000000' (expected 0-999999)"},
+				{Row: 2, CardNumber: "10058402", Column: "PIN", Err: "invalid PIN '12a4' (expected 0-999999)"},
+			},
+		},
+		{
+			name: "doors",
+			records: [][]string{
+				{"10058400", "", "2025-01-01", "2025-12-31", "1", "N"},
+				{"10058402", "", "2025-01-01", "2025-12-31", "yes", "N"},
+				{"10058403", "", "2025-01-01", "2025-12-31", "Y", ""},
+			},
+			valid: [][]string{
+				{"10058403", "", "2025-01-01", "2025-12-31", "Y", "N"},
+			},
+			errors: []rowError{
+				{Row: 1, CardNumber: "10058400", Column: "Great Hall", Err: "invalid time profile '1' (expected 2-254)"},
+				{Row: 2, CardNumber: "10058402", Column: "Great Hall", Err: "invalid door permission 'yes' (expected Y, N or a time profile)"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		table := lib.Table{
+			Header:  header,
+			Records: test.records,
+		}
+
+		valid, errors, err := validateACL(table, devices, db.DefaultColumns, true)
+		if err != nil {
+			t.Fatalf("%v: unexpected error (%v)", test.name, err)
+		}
+
+		if !reflect.DeepEqual(valid.Records, test.valid) {
+			t.Errorf("%v: incorrect valid records\n   expected:%q\n   got:     %q", test.name, test.valid, valid.Records)
+		}
+
+		if !reflect.DeepEqual(errors, test.errors) {
+			t.Errorf("%v: incorrect errors\n   expected:%+v\n   got:     %+v", test.name, test.errors, errors)
+		}
+	}
+}
+
+func TestValidateACLHeader(t *testing.T) {
+	devices := []uhppote.Device{
+		{DeviceID: 405419896, Doors: []string{"Great Hall", "Gryffindor"}},
+	}
+
+	tests := []struct {
+		header []string
+		err    bool
+	}{
+		{[]string{"Card Number", "From", "To", "Great Hall"}, false},
+		{[]string{"Name", "Card Number", "From", "To", "Great Hall", "Status", "Controllers"}, false},
+		{[]string{"Card Number", "From", "To", "Kitchen"}, true},
+	}
+
+	for _, test := range tests {
+		table := lib.Table{
+			Header:  test.header,
+			Records: [][]string{},
+		}
+
+		if _, _, err := validateACL(table, devices, db.DefaultColumns, false); err != nil && !test.err {
+			t.Errorf("%q: unexpected error (%v)", test.header, err)
+		} else if err == nil && test.err {
+			t.Errorf("%q: expected error, got nil", test.header)
+		}
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected [][]string
+		err      bool
+	}{
+		{
+			name: "records",
+			json: `[
+			  { "Card Number": 10058400, "From": "2025-01-01", "To": "2025-12-31", "Great Hall": true,  "Gryffindor": 29 },
+			  { "Card Number": 10058401, "From": "2025-01-01", "To": "2025-12-31", "Great Hall": false, "PIN": null }
+			]`,
+			expected: [][]string{
+				{"Card Number", "From", "To", "Great Hall", "Gryffindor", "PIN"},
+				{"10058400", "2025-01-01", "2025-12-31", "Y", "29", ""},
+				{"10058401", "2025-01-01", "2025-12-31", "N", "", ""},
+			},
+		},
+		{
+			name: "not an array",
+			json: `{ "Card Number": 10058400 }`,
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		file := filepath.Join(t.TempDir(), "acl.json")
+		if err := os.WriteFile(file, []byte(test.json), 0600); err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		records, err := readJSON(file)
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected error, got %q", test.name, records)
+			}
+		} else if err != nil {
+			t.Errorf("%v: unexpected error (%v)", test.name, err)
+		} else if !reflect.DeepEqual(records, test.expected) {
+			t.Errorf("%v: incorrect records\n   expected:%q\n   got:     %q", test.name, test.expected, records)
+		}
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	files := map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets><sheet name="ACL" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`,
+
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`,
+
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <si><t>Card Number</t></si>
+  <si><t>From</t></si>
+  <si><r><t>Great </t></r><r><t>Hall</t></r></si>
+</sst>`,
+
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="s"><v>2</v></c></row>
+    <row r="2"><c r="A2"><v>1.00584E7</v></c><c r="B2"><v>45658</v></c><c r="D2" t="b"><v>1</v></c></row>
+    <row r="3"><c r="A3" t="n"><v>10058401</v></c><c r="B3" t="inlineStr"><is><t>2025-01-01</t></is></c><c r="D3" t="b"><v>0</v></c></row>
+    <row r="4"><c r="A4"><v>10058402</v></c><c r="B4" t="d"><v>2025-01-01T00:00:00</v></c><c r="D4" t="b"><v>1</v></c></row>
+  </sheetData>
+</worksheet>`,
+	}
+
+	file := filepath.Join(t.TempDir(), "acl.xlsx")
+	if f, err := os.Create(file); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		w := zip.NewWriter(f)
+		for name, content := range files {
+			if z, err := w.Create(name); err != nil {
+				t.Fatalf("%v", err)
+			} else if _, err := z.Write([]byte(content)); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+
+		w.Close()
+		f.Close()
+	}
+
+	expected := [][]string{
+		{"Card Number", "From", "", "Great Hall"},
+		{"10058400", "45658", "", "Y"},
+		{"10058401", "2025-01-01", "", "N"},
+		{"10058402", "2025-01-01", "", "Y"},
+	}
+
+	if records, err := readXLSX(file); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if !reflect.DeepEqual(records, expected) {
+		t.Errorf("incorrect records\n   expected:%q\n   got:     %q", expected, records)
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected int
+		err      bool
+	}{
+		{"A1", 0, false},
+		{"C7", 2, false},
+		{"AA1", 26, false},
+		{"17", 0, true},
+	}
+
+	for _, test := range tests {
+		col, err := xlsxColumn(test.ref)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error, got %v", test.ref, col)
+			}
+		} else if err != nil {
+			t.Errorf("%q: unexpected error (%v)", test.ref, err)
+		} else if col != test.expected {
+			t.Errorf("%q: incorrect column - expected:%v, got:%v", test.ref, test.expected, col)
+		}
+	}
+}
+
+func TestFromSerialDates(t *testing.T) {
+	records := [][]string{
+		{"Card Number", "From", "To", "Great Hall"},
+		{"10058400", "45658", "46022", "1"},
+		{"10058401", "2025-01-01", "2025-12-31", "Y"},
+		{"10058402", "0", ""},
+	}
+
+	expected := [][]string{
+		{"Card Number", "From", "To", "Great Hall"},
+		{"10058400", "2025-01-01", "2025-12-31", "1"},
+		{"10058401", "2025-01-01", "2025-12-31", "Y"},
+		{"10058402", "0", ""},
+	}
+
+	if converted := fromSerialDates(records); !reflect.DeepEqual(converted, expected) {
+		t.Errorf("incorrect dates\n   expected:%q\n   got:     %q", expected, converted)
+	}
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestGetTableFallsBackToCache(t *testing.T) {
+	dir := t.TempDir()
+	keyfile := filepath.Join(dir, "cache.key")
+
+	if err := os.WriteFile(keyfile, []byte("0123456789abcdef0123456789abcdef"), 0600); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	noACL := filepath.Join(dir, "events.db")
+	if dbc, err := sql.Open("sqlite3", noACL); err != nil {
+		t.Fatalf("%v", err)
+	} else if _, err := dbc.Exec(`CREATE TABLE Events (EventIndex INTEGER);`); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		dbc.Close()
+	}
+
+	cached := lib.Table{
+		Header: []string{"Card Number", "From", "To", "Great Hall"},
+		Records: [][]string{
+			{"10058400", "2025-01-01", "2035-12-31", "Y"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		dsn    string
+		cached bool
+		err    bool
+	}{
+		{"DB unavailable", "sqlite3://" + filepath.Join(dir, "missing.db"), true, false},
+		{"missing ACL table", "sqlite3://" + noACL, false, true},
+	}
+
+	for _, test := range tests {
+		cache, err := newACLCache(filepath.Join(dir, "cache"), keyfile, test.dsn)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		} else if err := cache.save(cached); err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		cmd := LoadACL{
+			command: command{
+				name:   "load-acl",
+				dsn:    test.dsn,
+				tables: tables{ACL: "ACL"},
+			},
+		}
+
+		table, fromCache, err := cmd.getTable(db.DefaultColumns, cache)
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected error, got %v", test.name, table)
+			}
+		} else if err != nil {
+			t.Errorf("%v: unexpected error (%v)", test.name, err)
+		} else if fromCache != test.cached {
+			t.Errorf("%v: incorrect cached flag - expected:%v, got:%v", test.name, test.cached, fromCache)
+		} else if !reflect.DeepEqual(table, cached) {
+			t.Errorf("%v: incorrect ACL\n   expected:%q\n   got:     %q", test.name, cached, table)
+		}
+	}
+}
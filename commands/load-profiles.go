@@ -0,0 +1,206 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppote-core/uhppote"
+	"github.com/uhppoted/uhppoted-app-db/db"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var LoadProfilesCmd = LoadProfiles{
+	command: command{
+		name:        "load-profiles",
+		description: "Retrieves the time profiles from a database and updates the configured set of access controllers",
+		usage:       "--dsn <DSN> [--table:profiles <table>] [--table:holidays <table>] [--table:audit <table>] [-table:log <table>]",
+
+		dsn: "",
+		tables: tables{
+			Profiles: "TimeProfiles",
+			Holidays: "",
+			Audit:    "",
+			Log:      "",
+		},
+		lockfile: "",
+		config:   config.DefaultConfig,
+		debug:    false,
+	},
+}
+
+type LoadProfiles struct {
+	command
+}
+
+func (cmd *LoadProfiles) Help() {
+	fmt.Println()
+	fmt.Printf("  Usage: %s [--debug] [--config <file>] load-profiles --dsn <DSN> [--table:profiles <table>] [--table:holidays <table>] [--table:audit <table>] [-table:log <table>]\n", APP)
+	fmt.Println()
+	fmt.Println("  Retrieves the time profiles from a database and updates the configured set of access controllers. The")
+	fmt.Println("  holidays in the optional holidays table are excluded from the time profiles by splitting the time profile")
+	fmt.Println("  date ranges around the holidays into linked time profiles with unused time profile IDs.")
+	fmt.Println()
+
+	helpOptions(cmd.FlagSet())
+
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println(`    uhppote-app-db --debug load-profiles --dsn "sqlite3://./db/ACL.db"`)
+	fmt.Println(`    uhppote-app-db --debug load-profiles --dsn "sqlite3://./db/ACL.db" --table:profiles TimeProfiles --table:log OpsLog`)
+	fmt.Println(`    uhppote-app-db --debug load-profiles --dsn "sqlite3://./db/ACL.db" --table:holidays Holidays --table:audit Audit`)
+	fmt.Println()
+}
+
+func (cmd *LoadProfiles) FlagSet() *flag.FlagSet {
+	flagset := flag.NewFlagSet("load-profiles", flag.ExitOnError)
+
+	flagset.StringVar(&cmd.dsn, "dsn", cmd.dsn, "DSN for database")
+	flagset.StringVar(&cmd.tables.Profiles, "table:profiles", cmd.tables.Profiles, "Time profiles table name. Defaults to TimeProfiles")
+	flagset.StringVar(&cmd.tables.Holidays, "table:holidays", cmd.tables.Holidays, "Optional holidays table name. Defaults to ''")
+	flagset.StringVar(&cmd.tables.Audit, "table:audit", cmd.tables.Audit, "Audit trail table name for the holidays added and removed. Defaults to ''")
+	flagset.StringVar(&cmd.tables.Log, "table:log", cmd.tables.Log, "Operations log table name. Defaults to ''")
+	flagset.StringVar(&cmd.lockfile, "lockfile", cmd.lockfile, "Filepath for lock file. Defaults to <tmp>/uhppoted-app-db.lock")
+	flagset.StringVar(&cmd.profile, "profile", cmd.profile, "Optional named profile from the configuration file with the DSN, tables and options")
+
+	cmd.flagset = flagset
+
+	return flagset
+}
+
+func (cmd *LoadProfiles) Execute(args ...any) error {
+	options := args[0].(*Options)
+
+	cmd.started = time.Now()
+
+	cmd.config = options.Config
+	cmd.debug = options.Debug
+
+	// ... check parameters
+	if err := cmd.resolve(); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(cmd.tables.Profiles) == "" {
+		return fmt.Errorf("invalid time profiles table")
+	}
+
+	// ... locked?
+	if kraken, err := lock(cmd.lockfile); err != nil {
+		return err
+	} else {
+		defer func() {
+			cmd.infof("load-profiles", "removing lockfile")
+			kraken.Release()
+		}()
+	}
+
+	// ... get config
+	conf := config.NewConfig()
+	if err := conf.Load(cmd.config); err != nil {
+		return fmt.Errorf("could not load configuration (%v)", err)
+	}
+
+	u, devices := getDevices(conf, cmd.timeout, cmd.debug)
+
+	// ... retrieve time profiles from DB
+	profiles, err := getTimeProfiles(cmd.dsn, cmd.tls, cmd.tables.Profiles)
+	if err != nil {
+		return err
+	}
+
+	profiles, err = sortProfiles(profiles)
+	if err != nil {
+		return err
+	}
+
+	// ... retrieve holidays from DB
+	holidays := []db.Holiday{}
+	if cmd.tables.Holidays != "" {
+		if holidays, err = getHolidays(cmd.dsn, cmd.tls, cmd.tables.Holidays); err != nil {
+			return err
+		}
+	}
+
+	// ... update controllers
+	recordset := []db.LogRecord{}
+	trail := []db.AuditRecord{}
+
+	for _, device := range devices {
+		controller := device.DeviceID
+		list := holidaysFor(holidays, controller)
+
+		expanded, err := expandHolidays(profiles, list)
+		if err != nil {
+			return fmt.Errorf("%v: %v", controller, err)
+		}
+
+		expanded, err = sortProfiles(expanded)
+		if err != nil {
+			return fmt.Errorf("%v: %v", controller, err)
+		}
+
+		updated, errors := cmd.load(u, controller, expanded)
+
+		cmd.infof("load-profiles", "%v  updated:%v  errors:%v  holidays:%v", controller, updated, errors, len(list))
+
+		recordset = append(recordset, db.LogRecord{
+			Timestamp:  time.Now(),
+			Operation:  "load-profiles",
+			Controller: controller,
+			Detail:     fmt.Sprintf("updated:%-4v errors:%-4v holidays:%-4v", updated, errors, len(list)),
+			Counters: map[string]int{
+				"updated":  updated,
+				"errors":   errors,
+				"holidays": len(list),
+			},
+		})
+
+		// ... holidays are only audited once the time profiles have been loaded without errors
+		if cmd.tables.Holidays != "" && cmd.tables.Audit != "" && errors == 0 {
+			if records, err := cmd.holidayTrail(controller, list); err != nil {
+				return err
+			} else {
+				trail = append(trail, records...)
+			}
+		}
+	}
+
+	// ... add audit trail
+	if cmd.tables.Audit != "" && len(trail) > 0 {
+		if err := cmd.stashToAudit(trail); err != nil {
+			return err
+		}
+	}
+
+	// ... add operations log
+	if cmd.tables.Log != "" {
+		if err := cmd.stashToLog(elapsed(recordset, cmd.started)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cmd *LoadProfiles) load(u uhppote.IUHPPOTE, controller uint32, profiles []core.TimeProfile) (int, int) {
+	updated := 0
+	errors := 0
+
+	for _, profile := range profiles {
+		if ok, err := u.SetTimeProfile(controller, profile); err != nil {
+			cmd.errorf("load-profiles", "%v  time profile %v (%v)", controller, profile.ID, err)
+			errors++
+		} else if !ok {
+			cmd.errorf("load-profiles", "%v  time profile %v not updated", controller, profile.ID)
+			errors++
+		} else {
+			cmd.debugf("load-profiles", "%v  time profile %v", controller, profile)
+			updated++
+		}
+	}
+
+	return updated, errors
+}
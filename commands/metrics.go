@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+	"github.com/uhppoted/uhppoted-app-db/metrics"
+)
+
+// instrumented wraps a DB backend to record the latency and errors of each DB operation.
+type instrumented struct {
+	dbi     db.DB
+	backend string
+}
+
+func (d instrumented) GetACL(table string, columns db.Columns, withPIN bool) (*lib.Table, error) {
+	defer d.observe("get-acl", time.Now())
+
+	t, err := d.dbi.GetACL(table, columns, withPIN)
+
+	return t, d.failed("get-acl", err)
+}
+
+func (d instrumented) PutACL(table string, acl lib.Table, columns db.Columns, withPIN bool) (int, error) {
+	defer d.observe("put-acl", time.Now())
+
+	N, err := d.dbi.PutACL(table, acl, columns, withPIN)
+
+	return N, d.failed("put-acl", err)
+}
+
+func (d instrumented) GetEvents(table string, controller uint32) ([]uint32, error) {
+	defer d.observe("get-events", time.Now())
+
+	events, err := d.dbi.GetEvents(table, controller)
+
+	return events, d.failed("get-events", err)
+}
+
+func (d instrumented) GetControllers(table string) ([]uint32, error) {
+	defer d.observe("get-controllers", time.Now())
+
+	controllers, err := d.dbi.GetControllers(table)
+
+	return controllers, d.failed("get-controllers", err)
+}
+
+func (d instrumented) PutEvents(table string, events []core.Event) (int, error) {
+	defer d.observe("put-events", time.Now())
+
+	N, err := d.dbi.PutEvents(table, events)
+
+	return N, d.failed("put-events", err)
+}
+
+func (d instrumented) AuditTrail(table string, trail []db.AuditRecord) (int, error) {
+	defer d.observe("audit-trail", time.Now())
+
+	N, err := d.dbi.AuditTrail(table, trail)
+
+	return N, d.failed("audit-trail", err)
+}
+
+func (d instrumented) Log(table string, rs []db.LogRecord) (int, error) {
+	defer d.observe("log", time.Now())
+
+	N, err := d.dbi.Log(table, rs)
+
+	return N, d.failed("log", err)
+}
+
+func (d instrumented) GetGroups(table string, columns db.Columns) ([]db.Group, error) {
+	defer d.observe("get-groups", time.Now())
+
+	groups, err := d.dbi.GetGroups(table, columns)
+
+	return groups, d.failed("get-groups", err)
+}
+
+func (d instrumented) GetGroupMembers(table string) ([]db.GroupMember, error) {
+	defer d.observe("get-group-members", time.Now())
+
+	members, err := d.dbi.GetGroupMembers(table)
+
+	return members, d.failed("get-group-members", err)
+}
+
+func (d instrumented) GetTimeProfiles(table string) ([]core.TimeProfile, error) {
+	defer d.observe("get-time-profiles", time.Now())
+
+	profiles, err := d.dbi.GetTimeProfiles(table)
+
+	return profiles, d.failed("get-time-profiles", err)
+}
+
+func (d instrumented) GetHolidays(table string) ([]db.Holiday, error) {
+	defer d.observe("get-holidays", time.Now())
+
+	holidays, err := d.dbi.GetHolidays(table)
+
+	return holidays, d.failed("get-holidays", err)
+}
+
+func (d instrumented) Query(table string, filter db.Filter) ([]db.Record, error) {
+	defer d.observe("query", time.Now())
+
+	records, err := d.dbi.Query(table, filter)
+
+	return records, d.failed("query", err)
+}
+
+func (d instrumented) CheckPermissions(table string, column string, operations []db.Operation) ([]db.Permission, error) {
+	defer d.observe("check-permissions", time.Now())
+
+	permissions, err := d.dbi.CheckPermissions(table, column, operations)
+
+	return permissions, d.failed("check-permissions", err)
+}
+
+func (d instrumented) ExpireCards(table string, columns db.Columns, cards []uint32, status string) (int, error) {
+	defer d.observe("expire-cards", time.Now())
+
+	N, err := d.dbi.ExpireCards(table, columns, cards, status)
+
+	return N, d.failed("expire-cards", err)
+}
+
+func (d instrumented) observe(operation string, start time.Time) {
+	labels := metrics.Labels{"backend": d.backend, "operation": operation}
+
+	metrics.Observe(metrics.DBLatency, labels, time.Since(start).Seconds())
+}
+
+func (d instrumented) failed(operation string, err error) error {
+	if err != nil {
+		metrics.Add(metrics.DBErrors, metrics.Labels{"backend": d.backend, "operation": operation}, 1)
+	}
+
+	return err
+}
+
+func controllerLabel(controller uint32) metrics.Labels {
+	return metrics.Labels{"controller": fmt.Sprintf("%v", controller)}
+}
+
+func reportMetrics(report map[uint32]lib.Report) {
+	for _, v := range lib.Summarize(report) {
+		controller := fmt.Sprintf("%v", v.DeviceID)
+
+		for result, N := range map[string]int{
+			"unchanged": v.Unchanged,
+			"updated":   v.Updated,
+			"added":     v.Added,
+			"deleted":   v.Deleted,
+			"failed":    v.Failed,
+			"errored":   v.Errored,
+		} {
+			metrics.Add(metrics.LoadACLCards, metrics.Labels{"controller": controller, "result": result}, float64(N))
+		}
+	}
+}
+
+func diffMetrics(diff lib.SystemDiff) {
+	for controller, v := range diff {
+		for kind, N := range map[string]int{
+			"incorrect":  len(v.Updated),
+			"missing":    len(v.Added),
+			"unexpected": len(v.Deleted),
+		} {
+			metrics.Set(metrics.CompareACLCards, metrics.Labels{"controller": fmt.Sprintf("%v", controller), "type": kind}, float64(N))
+		}
+	}
+}
+
+func eventMetrics(events []core.Event) {
+	latest := map[uint32]core.Event{}
+
+	for _, e := range events {
+		controller := uint32(e.SerialNumber)
+
+		metrics.Add(metrics.EventsStored, controllerLabel(controller), 1)
+
+		if v, ok := latest[controller]; !ok || e.Index > v.Index {
+			latest[controller] = e
+		}
+	}
+
+	for controller, e := range latest {
+		if !e.Timestamp.IsZero() {
+			metrics.Set(metrics.LastEventTimestamp, controllerLabel(controller), float64(time.Time(e.Timestamp).Unix()))
+		}
+	}
+}
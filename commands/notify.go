@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/notify"
+)
+
+// notifyDiff sends a notification summarising the incorrect, missing and unexpected cards found by
+// compare-acl.
+func (cmd command) notifyDiff(diff lib.SystemDiff) {
+	message := notify.Message{
+		Operation:   cmd.name,
+		Timestamp:   time.Now(),
+		Controllers: map[uint32]map[string]int{},
+	}
+
+	for controller, v := range diff {
+		message.Issues += len(v.Updated) + len(v.Added) + len(v.Deleted)
+		message.Controllers[controller] = map[string]int{
+			"incorrect":  len(v.Updated),
+			"missing":    len(v.Added),
+			"unexpected": len(v.Deleted),
+		}
+	}
+
+	cmd.notify(message)
+}
+
+// notifyReport sends a notification summarising the failed and errored cards (and any errors) from
+// load-acl.
+func (cmd command) notifyReport(report map[uint32]lib.Report, errors []error) {
+	message := notify.Message{
+		Operation:   cmd.name,
+		Timestamp:   time.Now(),
+		Issues:      len(errors),
+		Controllers: map[uint32]map[string]int{},
+		Errors:      []string{},
+	}
+
+	for controller, v := range report {
+		message.Issues += len(v.Failed) + len(v.Errored) + len(v.Errors)
+		message.Controllers[controller] = map[string]int{
+			"failed": len(v.Failed),
+			"errors": len(v.Errored) + len(v.Errors),
+		}
+
+		for _, err := range v.Errors {
+			message.Errors = append(message.Errors, fmt.Sprintf("%v  %v", controller, err))
+		}
+	}
+
+	for _, err := range errors {
+		message.Errors = append(message.Errors, fmt.Sprintf("%v", err))
+	}
+
+	cmd.notify(message)
+}
+
+// notifyError sends a notification for an operation that failed before any controllers were updated
+// e.g. because the DB is unavailable or the ACL is invalid.
+func (cmd command) notifyError(err error) {
+	message := notify.Message{
+		Operation:   cmd.name,
+		Timestamp:   time.Now(),
+		Issues:      1,
+		Controllers: map[uint32]map[string]int{},
+		Errors:      []string{fmt.Sprintf("%v", err)},
+	}
+
+	cmd.notify(message)
+}
+
+func (cmd command) notify(message notify.Message) {
+	if config, err := cmd.getNotifications(); err != nil {
+		cmd.warnf(cmd.name, "%v", err)
+	} else if err := notify.Notify(config, message); err != nil {
+		cmd.warnf(cmd.name, "error sending notification (%v)", err)
+	}
+}
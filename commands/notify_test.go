@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/notify"
+)
+
+func TestNotifications(t *testing.T) {
+	received := make(chan notify.Message, 16)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m notify.Message
+		if err := json.NewDecoder(r.Body).Decode(&m); err == nil {
+			received <- m
+		}
+	}))
+
+	defer server.Close()
+
+	cards := func(N int) []core.Card {
+		list := []core.Card{}
+		for i := range N {
+			list = append(list, core.Card{CardNumber: uint32(10058400 + i)})
+		}
+
+		return list
+	}
+
+	tests := []struct {
+		name     string
+		send     func(cmd command)
+		expected *notify.Message
+	}{
+		{
+			name: "diff",
+			send: func(cmd command) {
+				cmd.notifyDiff(lib.SystemDiff{
+					405419896: {Updated: cards(1), Added: cards(2), Deleted: cards(3)},
+					303986753: {Unchanged: cards(1)},
+				})
+			},
+			expected: &notify.Message{
+				Operation: "compare-acl",
+				Issues:    6,
+				Controllers: map[uint32]map[string]int{
+					405419896: {"incorrect": 1, "missing": 2, "unexpected": 3},
+					303986753: {"incorrect": 0, "missing": 0, "unexpected": 0},
+				},
+			},
+		},
+		{
+			name: "error",
+			send: func(cmd command) {
+				cmd.notifyError(fmt.Errorf("DB unavailable"))
+			},
+			expected: &notify.Message{
+				Operation:   "compare-acl",
+				Issues:      1,
+				Controllers: map[uint32]map[string]int{},
+				Errors:      []string{"DB unavailable"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		conf := filepath.Join(dir, "uhppoted.conf")
+		settings := "db.notify.webhook.url = " + server.URL + "\n" + "db.notify.state = " + filepath.Join(dir, "notify.json") + "\n"
+
+		if err := os.WriteFile(conf, []byte(settings), 0600); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		test.send(command{name: "compare-acl", config: conf})
+
+		select {
+		case m := <-received:
+			if test.expected == nil {
+				t.Errorf("%v: unexpected notification %+v", test.name, m)
+			} else if m.Timestamp.IsZero() {
+				t.Errorf("%v: missing notification timestamp", test.name)
+			} else if m.Timestamp = test.expected.Timestamp; !reflect.DeepEqual(m, *test.expected) {
+				t.Errorf("%v: incorrect notification\n   expected:%+v\n   got:     %+v", test.name, *test.expected, m)
+			}
+
+		default:
+			if test.expected != nil {
+				t.Errorf("%v: missing notification", test.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+const MASKED_PIN = "****"
+
+// showPIN returns true if card PINs should be included in the compare report and audit trail, i.e. if
+// the PINs are included and are not protected with a PIN key.
+func (cmd command) showPIN() bool {
+	return cmd.withPIN && cmd.pinKey == ""
+}
+
+// loadPINKey reads the AES-256 PIN key from the --pin-key file. The key file is expected to contain 64 hex
+// digits (e.g. generated with 'openssl rand -hex 32') and to not be accessible by other users.
+func (cmd command) loadPINKey() (cipher.AEAD, error) {
+	if cmd.pinKey == "" {
+		return nil, nil
+	}
+
+	if s, err := readSecret(cmd.pinKey); err != nil {
+		return nil, err
+	} else if key, err := hex.DecodeString(s); err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid PIN key file %v (expected 64 hex digits)", cmd.pinKey)
+	} else if block, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	} else {
+		return cipher.NewGCM(block)
+	}
+}
+
+// encryptPINs replaces the plaintext PINs in an ACL table with the encrypted PINs. Empty and already
+// encrypted PINs are left unchanged.
+func encryptPINs(table *lib.Table, key cipher.AEAD) error {
+	if key == nil {
+		return nil
+	}
+
+	return updatePINs(table, func(pin string) (string, error) {
+		if pin == "" || db.IsEncryptedPIN(pin) {
+			return pin, nil
+		}
+
+		nonce := make([]byte, key.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+
+		encrypted := key.Seal(nonce, nonce, []byte(pin), nil)
+
+		return db.EncryptedPINPrefix + base64.StdEncoding.EncodeToString(encrypted), nil
+	})
+}
+
+// decryptPINs replaces the encrypted PINs in an ACL table with the plaintext PINs.
+func decryptPINs(table *lib.Table, key cipher.AEAD) error {
+	return updatePINs(table, func(pin string) (string, error) {
+		if !db.IsEncryptedPIN(pin) {
+			return pin, nil
+		} else if key == nil {
+			return "", fmt.Errorf("ACL has encrypted PINs but no PIN key (--pin-key)")
+		}
+
+		encrypted, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, db.EncryptedPINPrefix))
+		if err != nil || len(encrypted) < key.NonceSize() {
+			return "", fmt.Errorf("invalid encrypted PIN")
+		}
+
+		nonce := encrypted[:key.NonceSize()]
+		if plaintext, err := key.Open(nil, nonce, encrypted[key.NonceSize():], nil); err != nil {
+			return "", fmt.Errorf("error decrypting PIN (%v)", err)
+		} else {
+			return string(plaintext), nil
+		}
+	})
+}
+
+// decrypted returns a copy of an ACL table with the encrypted PINs replaced by the plaintext PINs.
+func decrypted(table lib.Table, key cipher.AEAD) (lib.Table, error) {
+	records := [][]string{}
+	for _, record := range table.Records {
+		records = append(records, slices.Clone(record))
+	}
+
+	t := lib.Table{
+		Header:  slices.Clone(table.Header),
+		Records: records,
+	}
+
+	if err := decryptPINs(&t, key); err != nil {
+		return lib.Table{}, err
+	}
+
+	return t, nil
+}
+
+func updatePINs(table *lib.Table, f func(string) (string, error)) error {
+	for ix, h := range table.Header {
+		if strings.ReplaceAll(strings.ToLower(h), " ", "") == "pin" {
+			for _, row := range table.Records {
+				if ix < len(row) {
+					if pin, err := f(row[ix]); err != nil {
+						return err
+					} else {
+						row[ix] = pin
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// maskTablePINs replaces the (plaintext or encrypted) PINs in an ACL table with a mask. Empty PINs are
+// left unchanged.
+func maskTablePINs(table *lib.Table) {
+	updatePINs(table, func(pin string) (string, error) {
+		if pin == "" {
+			return pin, nil
+		}
+
+		return MASKED_PIN, nil
+	})
+}
+
+// maskPINs replaces the PIN values in the compare differences with a mask.
+func maskPINs(details diffDetails) {
+	for _, cards := range details {
+		for _, list := range cards {
+			for i := range list {
+				if list[i].Field == "PIN" {
+					list[i].DB = MASKED_PIN
+					list[i].Controller = MASKED_PIN
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"crypto/cipher"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+const (
+	PIN_KEY   = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	WRONG_KEY = "1f1e1d1c1b1a191817161514131211100f0e0d0c0b0a09080706050403020100"
+)
+
+func pinKey(t *testing.T, key string) command {
+	file := filepath.Join(t.TempDir(), "pin.key")
+	if err := os.WriteFile(file, []byte(key+"\n"), 0600); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	return command{pinKey: file}
+}
+
+func pinTable() lib.Table {
+	return lib.Table{
+		Header: []string{"Card Number", "PIN", "From", "To", "Great Hall"},
+		Records: [][]string{
+			{"10058400", "7531", "2025-01-01", "2025-12-31", "Y"},
+			{"10058401", "", "2025-01-01", "2025-12-31", "N"},
+			{"10058402", "999999", "2025-01-01", "2025-12-31", "Y"},
+		},
+	}
+}
+
+func TestEncryptDecryptPINs(t *testing.T) {
+	key, err := pinKey(t, PIN_KEY).loadPINKey()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	table := pinTable()
+
+	if err := encryptPINs(&table, key); err != nil {
+		t.Fatalf("error encrypting PINs (%v)", err)
+	}
+
+	for i, record := range table.Records {
+		if pin := pinTable().Records[i][1]; pin == "" && record[1] != "" {
+			t.Errorf("record %v: expected blank PIN to be unchanged, got %q", i+1, record[1])
+		} else if pin != "" && !db.IsEncryptedPIN(record[1]) {
+			t.Errorf("record %v: expected encrypted PIN, got %q", i+1, record[1])
+		}
+	}
+
+	if err := decryptPINs(&table, key); err != nil {
+		t.Fatalf("error decrypting PINs (%v)", err)
+	} else if !reflect.DeepEqual(table, pinTable()) {
+		t.Errorf("incorrect decrypted table\n   expected:%q\n   got:     %q", pinTable().Records, table.Records)
+	}
+}
+
+func TestDecryptPINsWithInvalidCiphertext(t *testing.T) {
+	key, err := pinKey(t, PIN_KEY).loadPINKey()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	wrong, err := pinKey(t, WRONG_KEY).loadPINKey()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	table := pinTable()
+	if err := encryptPINs(&table, key); err != nil {
+		t.Fatalf("error encrypting PINs (%v)", err)
+	}
+
+	encrypted := table.Records[0][1]
+
+	tampered := func() string {
+		b, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, db.EncryptedPINPrefix))
+		b[len(b)-1] ^= 0x01
+
+		return db.EncryptedPINPrefix + base64.StdEncoding.EncodeToString(b)
+	}()
+
+	tests := []struct {
+		name string
+		pin  string
+		key  cipher.AEAD
+	}{
+		{"wrong key", encrypted, wrong},
+		{"tampered ciphertext", tampered, key},
+	}
+
+	for _, test := range tests {
+		table := lib.Table{
+			Header:  []string{"Card Number", "PIN"},
+			Records: [][]string{{"10058400", test.pin}},
+		}
+
+		if err := decryptPINs(&table, test.key); err == nil {
+			t.Errorf("%v: expected error, got %q", test.name, table.Records[0][1])
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// profile is a named set of DSN, table and option settings defined in uhppoted.conf (or in the file
+// specified by db.profile-file) e.g.
+//
+//	db.profile.site-a.dsn = sqlite3://./db/site-a.db
+//	db.profile.site-a.table.ACL = ACL
+//	db.profile.site-a.table.audit = Audit
+//	db.profile.site-a.table.log = OperationsLog
+//	db.profile.site-a.with-pin = true
+//	db.profile.site-a.timeout = 2.5s
+//	db.profile.site-a.tls.mode = verify-full
+type profile map[string]string
+
+type profiles map[string]profile
+
+func (m *profiles) UnmarshalConf(tag string, values map[string]string) (any, error) {
+	p := profiles{}
+	prefix := tag + "."
+
+	for k, v := range values {
+		if suffix, ok := strings.CutPrefix(k, prefix); ok {
+			if name, key, ok := strings.Cut(suffix, "."); ok && strings.TrimSpace(name) != "" {
+				if _, ok := p[name]; !ok {
+					p[name] = profile{}
+				}
+
+				p[name][strings.TrimSpace(key)] = strings.TrimSpace(v)
+			}
+		}
+	}
+
+	return &p, nil
+}
+
+// applyProfile fills in the DSN, tables and options from the --profile profile for the settings that were
+// not specified on the command line.
+func (cmd *command) applyProfile() error {
+	if cmd.profile == "" {
+		return nil
+	}
+
+	s, err := loadSettings(cmd.config)
+	if err != nil {
+		return err
+	}
+
+	p, ok := s.Profiles[cmd.profile]
+	if !ok && s.ProfileFile != "" {
+		if t, err := loadSettings(s.ProfileFile); err != nil {
+			return err
+		} else {
+			p, ok = t.Profiles[cmd.profile]
+		}
+	}
+
+	if !ok {
+		return fmt.Errorf("unknown profile (%v)", cmd.profile)
+	}
+
+	explicit := map[string]bool{}
+	if cmd.flagset != nil {
+		cmd.flagset.Visit(func(f *flag.Flag) {
+			explicit[f.Name] = true
+		})
+	}
+
+	strs := map[string]*string{
+		"dsn":             &cmd.dsn,
+		"columns":         &cmd.columns,
+		"lockfile":        &cmd.lockfile,
+		"pin-key":         &cmd.pinKey,
+		"table:ACL":       &cmd.tables.ACL,
+		"table:audit":     &cmd.tables.Audit,
+		"table:events":    &cmd.tables.Events,
+		"table:log":       &cmd.tables.Log,
+		"table:groups":    &cmd.tables.Groups,
+		"table:members":   &cmd.tables.Members,
+		"table:profiles":  &cmd.tables.Profiles,
+		"table:holidays":  &cmd.tables.Holidays,
+		"table:history":   &cmd.tables.History,
+		"tls.mode":        &cmd.tls.Mode,
+		"tls.ca":          &cmd.tls.CA,
+		"tls.certificate": &cmd.tls.Certificate,
+		"tls.key":         &cmd.tls.Key,
+		"tls.server-name": &cmd.tls.ServerName,
+	}
+
+	for k, v := range p {
+		flag := strings.Replace(k, "table.", "table:", 1)
+
+		if explicit[flag] {
+			continue
+		}
+
+		if field, ok := strs[flag]; ok {
+			*field = v
+			continue
+		}
+
+		switch k {
+		case "with-pin":
+			if b, err := strconv.ParseBool(v); err != nil {
+				return fmt.Errorf("profile %v: invalid with-pin (%v)", cmd.profile, v)
+			} else {
+				cmd.withPIN = b
+			}
+
+		case "timeout":
+			if t, err := time.ParseDuration(v); err != nil || t <= 0 {
+				return fmt.Errorf("profile %v: invalid timeout (%v)", cmd.profile, v)
+			} else {
+				cmd.timeout = t
+			}
+
+		default:
+			return fmt.Errorf("profile %v: unknown setting (%v)", cmd.profile, k)
+		}
+	}
+
+	cmd.infof("profile", "using profile %v", cmd.profile)
+
+	return nil
+}
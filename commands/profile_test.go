@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApplyProfile(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name string, settings string) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(settings), 0600); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		return file
+	}
+
+	conf := write("uhppoted.conf", `
+db.profile.site-a.dsn = sqlite3://./db/site-a.db
+db.profile.site-a.table.ACL = ACL_A
+db.profile.site-a.table.audit = Audit
+db.profile.site-a.with-pin = true
+db.profile.site-a.timeout = 2.5s
+db.profile.bad-pin.with-pin = maybe
+`)
+
+	type result struct {
+		dsn     string
+		ACL     string
+		audit   string
+		withPIN bool
+		timeout time.Duration
+	}
+
+	tests := []struct {
+		name     string
+		profile  string
+		args     []string
+		expected result
+		err      bool
+	}{
+		{"no profile", "", nil, result{}, false},
+		{"profile", "site-a", nil, result{"sqlite3://./db/site-a.db", "ACL_A", "Audit", true, 2500 * time.Millisecond}, false},
+		{"command line", "site-a", []string{"--dsn", "sqlite3://./db/local.db", "--table:ACL", "ACL"}, result{"sqlite3://./db/local.db", "ACL", "Audit", true, 2500 * time.Millisecond}, false},
+		{"unknown profile", "site-c", nil, result{}, true},
+		{"invalid with-pin", "bad-pin", nil, result{}, true},
+	}
+
+	for _, test := range tests {
+		cmd := command{
+			config:  conf,
+			profile: test.profile,
+		}
+
+		flagset := flag.NewFlagSet("test", flag.ContinueOnError)
+		flagset.StringVar(&cmd.dsn, "dsn", cmd.dsn, "DSN")
+		flagset.StringVar(&cmd.tables.ACL, "table:ACL", cmd.tables.ACL, "ACL table")
+
+		if err := flagset.Parse(test.args); err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		cmd.flagset = flagset
+
+		err := cmd.applyProfile()
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected error, got nil", test.name)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%v: unexpected error (%v)", test.name, err)
+		}
+
+		got := result{cmd.dsn, cmd.tables.ACL, cmd.tables.Audit, cmd.withPIN, cmd.timeout}
+		if got != test.expected {
+			t.Errorf("%v: incorrect settings\n   expected:%+v\n   got:     %+v", test.name, test.expected, got)
+		}
+	}
+}
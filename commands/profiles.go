@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+// checkProfiles verifies that every time profile referenced by a door permission in the ACL table is
+// defined in the time profiles table.
+func checkProfiles(dsn string, tls db.TLS, table string, acl lib.Table) error {
+	if table == "" {
+		return nil
+	}
+
+	profiles, err := getTimeProfiles(dsn, tls, table)
+	if err != nil {
+		return err
+	}
+
+	defined := map[uint8]bool{}
+	for _, p := range profiles {
+		defined[p.ID] = true
+	}
+
+	missing := map[uint64]bool{}
+	for _, record := range acl.Records {
+		for i, h := range acl.Header {
+			switch normalise(h) {
+			case "cardnumber", "pin", "from", "to":
+				continue
+			}
+
+			if i < len(record) {
+				if profile, err := strconv.ParseUint(record[i], 10, 8); err == nil && profile > 1 && profile < 255 && !defined[uint8(profile)] {
+					missing[profile] = true
+				}
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		list := []uint64{}
+		for k := range missing {
+			list = append(list, k)
+		}
+
+		slices.Sort(list)
+
+		return fmt.Errorf("ACL uses undefined time profiles %v", list)
+	}
+
+	return nil
+}
+
+// sortProfiles orders the time profiles so that a linked profile is always set on a controller
+// before the profiles that link to it.
+func sortProfiles(profiles []core.TimeProfile) ([]core.TimeProfile, error) {
+	pending := map[uint8]core.TimeProfile{}
+	for _, p := range profiles {
+		if _, ok := pending[p.ID]; ok {
+			return nil, fmt.Errorf("duplicate time profile %v", p.ID)
+		}
+
+		pending[p.ID] = p
+	}
+
+	sorted := []core.TimeProfile{}
+	done := map[uint8]bool{}
+
+	for len(pending) > 0 {
+		ids := []uint8{}
+		for id, p := range pending {
+			linked := p.LinkedProfileID
+			if _, ok := pending[linked]; linked == 0 || done[linked] || !ok {
+				ids = append(ids, id)
+			}
+		}
+
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("circular time profile links")
+		}
+
+		slices.Sort(ids)
+
+		for _, id := range ids {
+			p := pending[id]
+			if _, ok := pending[p.LinkedProfileID]; p.LinkedProfileID != 0 && !done[p.LinkedProfileID] && !ok {
+				warnf("profiles", "time profile %v is linked to undefined time profile %v", p.ID, p.LinkedProfileID)
+			}
+
+			sorted = append(sorted, p)
+			done[id] = true
+			delete(pending, id)
+		}
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestSortProfiles(t *testing.T) {
+	profile := func(id, linked uint8) core.TimeProfile {
+		return core.TimeProfile{ID: id, LinkedProfileID: linked}
+	}
+
+	tests := []struct {
+		name     string
+		profiles []core.TimeProfile
+		expected []uint8
+		err      bool
+	}{
+		{"linked", []core.TimeProfile{profile(29, 30), profile(30, 31), profile(31, 0)}, []uint8{31, 30, 29}, false},
+		{"linked tree", []core.TimeProfile{profile(2, 10), profile(3, 10), profile(10, 0), profile(4, 2)}, []uint8{10, 2, 3, 4}, false},
+		{"cycle", []core.TimeProfile{profile(29, 30), profile(30, 31), profile(31, 29)}, nil, true},
+		{"duplicate", []core.TimeProfile{profile(29, 0), profile(30, 0), profile(29, 0)}, nil, true},
+	}
+
+	for _, test := range tests {
+		sorted, err := sortProfiles(test.profiles)
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected error, got %v", test.name, sorted)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%v: unexpected error (%v)", test.name, err)
+		}
+
+		ids := []uint8{}
+		for _, p := range sorted {
+			ids = append(ids, p.ID)
+		}
+
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%v: incorrect profile order - expected:%v, got:%v", test.name, test.expected, ids)
+		}
+	}
+}
+
+func TestCheckProfiles(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "profiles.db")
+
+	if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+		t.Fatalf("%v", err)
+	} else if _, err := dbc.Exec(`CREATE TABLE TimeProfiles (
+	    ProfileID       INTEGER NOT NULL UNIQUE,
+	    StartDate       TEXT    NOT NULL,
+	    EndDate         TEXT    NOT NULL,
+	    Segment1Start   TEXT    DEFAULT '',
+	    Segment1End     TEXT    DEFAULT ''
+	);
+	INSERT INTO TimeProfiles (ProfileID, StartDate, EndDate, Segment1Start, Segment1End) VALUES (29, '2025-01-01', '2025-12-31', '09:00', '17:00');
+	INSERT INTO TimeProfiles (ProfileID, StartDate, EndDate, Segment1Start, Segment1End) VALUES (30, '2025-01-01', '2025-12-31', '09:00', '17:00');`); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		dbc.Close()
+	}
+
+	header := []string{"Card Number", "PIN", "From", "To", "Great Hall", "Gryffindor"}
+
+	tests := []struct {
+		name    string
+		table   string
+		records [][]string
+		err     bool
+	}{
+		{"defined profiles", "TimeProfiles", [][]string{{"10058400", "7531", "2025-01-01", "2025-12-31", "29", "30"}}, false},
+		{"PIN is not a profile", "TimeProfiles", [][]string{{"10058400", "75", "2025-01-01", "2025-12-31", "Y", "N"}}, false},
+		{"undefined profile", "TimeProfiles", [][]string{{"10058400", "", "2025-01-01", "2025-12-31", "29", "75"}}, true},
+	}
+
+	for _, test := range tests {
+		acl := lib.Table{
+			Header:  header,
+			Records: test.records,
+		}
+
+		err := checkProfiles("sqlite3://"+dsn, db.TLS{}, test.table, acl)
+		if test.err && err == nil {
+			t.Errorf("%v: expected error, got nil", test.name)
+		} else if !test.err && err != nil {
+			t.Errorf("%v: unexpected error (%v)", test.name, err)
+		}
+	}
+}
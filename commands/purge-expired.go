@@ -0,0 +1,303 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/uhppote"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+	"github.com/uhppoted/uhppoted-lib/config"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+var PurgeExpiredCmd = PurgeExpired{
+	command: command{
+		name:        "purge-expired",
+		description: "Deletes the cards with an expired end date in the ACL table from the configured set of access controllers",
+		usage:       "[--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:audit <table>] [--table:log <table>] [--grace <days>] [--mark <status> | --remove]",
+
+		dsn: "",
+		tables: tables{
+			ACL: "ACL",
+		},
+		lockfile: "",
+		config:   config.DefaultConfig,
+		debug:    false,
+	},
+	grace:  0,
+	mark:   "",
+	remove: false,
+}
+
+type PurgeExpired struct {
+	command
+	grace  uint
+	mark   string
+	remove bool
+}
+
+func (cmd *PurgeExpired) Help() {
+	fmt.Println()
+	fmt.Printf("  Usage: %s [--debug] [--config <file>] purge-expired [--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:audit <table>] [--table:log <table>] [--grace <days>] [--mark <status> | --remove]\n", APP)
+	fmt.Println()
+	fmt.Println("  Deletes the cards in the ACL table with an end date more than the grace period before today from the")
+	fmt.Println("  configured set of access controllers, without loading the full ACL. The expired cards can optionally")
+	fmt.Println("  be marked with a status or removed from the ACL table.")
+	fmt.Println()
+
+	helpOptions(cmd.FlagSet())
+
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println(`    uhppote-app-db purge-expired --dsn "sqlite3://./db/ACL.db" --grace 7 --table:audit Audit`)
+	fmt.Println(`    uhppote-app-db purge-expired --dsn "sqlite3://./db/ACL.db" --mark expired --table:audit Audit --table:log OpsLog`)
+	fmt.Println()
+}
+
+func (cmd *PurgeExpired) FlagSet() *flag.FlagSet {
+	flagset := flag.NewFlagSet("purge-expired", flag.ExitOnError)
+
+	flagset.StringVar(&cmd.dsn, "dsn", cmd.dsn, "DSN for database")
+	flagset.StringVar(&cmd.tables.ACL, "table:ACL", cmd.tables.ACL, "ACL table name. Defaults to ACL")
+	flagset.StringVar(&cmd.tables.Audit, "table:audit", cmd.tables.Audit, "Audit trail table name. Defaults to ''")
+	flagset.StringVar(&cmd.tables.Log, "table:log", cmd.tables.Log, "Operations log table name. Defaults to ''")
+	flagset.UintVar(&cmd.grace, "grace", cmd.grace, "Number of days after the card end date before a card is purged. Defaults to 0")
+	flagset.StringVar(&cmd.mark, "mark", cmd.mark, "Optional status to set for the purged cards in the ACL table status column (e.g. expired)")
+	flagset.BoolVar(&cmd.remove, "remove", cmd.remove, "Removes the purged cards from the ACL table")
+	flagset.StringVar(&cmd.columns, "columns", cmd.columns, "Optional file with the ACL table column mapping. Defaults to the mapping in the configuration file")
+	flagset.StringVar(&cmd.lockfile, "lockfile", cmd.lockfile, "Filepath for lock file. Defaults to <tmp>/uhppoted-app-db.lock")
+	flagset.StringVar(&cmd.profile, "profile", cmd.profile, "Optional named profile from the configuration file with the DSN, tables and options")
+
+	cmd.flagset = flagset
+
+	return flagset
+}
+
+func (cmd *PurgeExpired) Execute(args ...any) error {
+	options := args[0].(*Options)
+
+	cmd.started = time.Now()
+
+	cmd.config = options.Config
+	cmd.debug = options.Debug
+
+	// ... check parameters
+	if err := cmd.resolve(); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(cmd.tables.ACL) == "" {
+		return fmt.Errorf("invalid ACL table")
+	}
+
+	if cmd.remove && cmd.mark != "" {
+		return fmt.Errorf("--mark and --remove are mutually exclusive")
+	}
+
+	// ... locked?
+	if kraken, err := lock(cmd.lockfile); err != nil {
+		return err
+	} else {
+		defer func() {
+			cmd.infof("purge-expired", "removing lockfile")
+			kraken.Release()
+		}()
+	}
+
+	// ... get config
+	conf := config.NewConfig()
+	if err := conf.Load(cmd.config); err != nil {
+		return fmt.Errorf("could not load configuration (%v)", err)
+	}
+
+	u, devices := getDevices(conf, cmd.timeout, cmd.debug)
+
+	// ... get ACL table column mapping
+	columns, err := cmd.getColumns()
+	if err != nil {
+		return err
+	}
+
+	// ... find expired cards
+	cutoff := time.Now().AddDate(0, 0, -int(cmd.grace))
+
+	table, err := getACL(cmd.dsn, cmd.tls, cmd.tables.ACL, columns, false)
+	if err != nil {
+		return err
+	}
+
+	if cmd.mark != "" && !slices.ContainsFunc(table.Header, func(h string) bool { return normalise(h) == "status" }) {
+		return fmt.Errorf("--mark requires a '%v' status column in the %v table", columns.Status, cmd.tables.ACL)
+	}
+
+	cards, err := cmd.expired(table, cutoff)
+	if err != nil {
+		return err
+	} else if len(cards) == 0 {
+		cmd.infof("purge-expired", "no cards expired before %v", cutoff.Format("2006-01-02"))
+		return nil
+	}
+
+	// ... delete from controllers
+	trail, errors := cmd.purge(u, devices, cards)
+
+	if cmd.tables.Audit != "" && len(trail) > 0 {
+		if err := cmd.stashToAudit(trail); err != nil {
+			return err
+		}
+	}
+
+	if cmd.tables.Log != "" {
+		recordset := purge2log(devices, trail)
+		if err := cmd.stashToLog(elapsed(recordset, cmd.started)); err != nil {
+			return err
+		}
+	}
+
+	// ... update ACL table (only the cards that were purged from every controller)
+	if purged := purged(cards, trail); (cmd.mark != "" || cmd.remove) && len(purged) > 0 {
+		if N, err := expireCards(cmd.dsn, cmd.tls, cmd.tables.ACL, columns, purged, cmd.mark); err != nil {
+			return err
+		} else if cmd.remove {
+			cmd.infof("purge-expired", "removed %v expired cards from %v", N, cmd.tables.ACL)
+		} else {
+			cmd.infof("purge-expired", "marked %v expired cards in %v as %v", N, cmd.tables.ACL, cmd.mark)
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("%v", errors)
+	}
+
+	return nil
+}
+
+// purge deletes the cards from each of the controllers, returning the audit trail of the cards that were
+// deleted along with any errors. Cards that are not on a controller are ignored.
+func (cmd *PurgeExpired) purge(u uhppote.IUHPPOTE, devices []uhppote.Device, cards []uint32) ([]db.AuditRecord, []error) {
+	now := time.Now()
+	trail := []db.AuditRecord{}
+	errors := []error{}
+
+	for _, device := range devices {
+		controller := device.DeviceID
+		deleted := 0
+
+		for _, card := range cards {
+			if ok, err := u.DeleteCard(controller, card); err != nil {
+				cmd.errorf("purge-expired", "%v  error deleting card %v (%v)", controller, card, err)
+				errors = append(errors, fmt.Errorf("%v: %v", controller, err))
+
+				trail = append(trail, db.AuditRecord{
+					Timestamp:  now,
+					Operation:  "purge",
+					Controller: controller,
+					CardNumber: card,
+					Status:     "error",
+				})
+			} else if ok {
+				deleted++
+
+				trail = append(trail, db.AuditRecord{
+					Timestamp:  now,
+					Operation:  "purge",
+					Controller: controller,
+					CardNumber: card,
+					Status:     "expired",
+				})
+			}
+		}
+
+		cmd.infof("purge-expired", "%v  expired:%v  deleted:%v", controller, len(cards), deleted)
+	}
+
+	return trail, errors
+}
+
+// expired returns the card numbers in the ACL table with an end date before the cutoff date.
+func (cmd *PurgeExpired) expired(table lib.Table, cutoff time.Time) ([]uint32, error) {
+	cardnumber := -1
+	to := -1
+
+	for i, h := range table.Header {
+		switch normalise(h) {
+		case "cardnumber":
+			cardnumber = i
+		case "to":
+			to = i
+		}
+	}
+
+	if cardnumber < 0 {
+		return nil, fmt.Errorf("missing 'card number' column")
+	} else if to < 0 {
+		return nil, fmt.Errorf("missing 'end date' column")
+	}
+
+	date := cutoff.Format("2006-01-02")
+	cards := []uint32{}
+
+	for _, record := range table.Records {
+		if to >= len(record) || cardnumber >= len(record) || record[to] == "" || record[to] >= date {
+			continue
+		}
+
+		if card, err := strconv.ParseUint(record[cardnumber], 10, 32); err != nil {
+			cmd.warnf("purge-expired", "invalid card number (%v)", record[cardnumber])
+		} else if !slices.Contains(cards, uint32(card)) {
+			cmd.debugf("purge-expired", "card %v expired %v", card, record[to])
+			cards = append(cards, uint32(card))
+		}
+	}
+
+	return cards, nil
+}
+
+// purged returns the cards that were not in error on any controller.
+func purged(cards []uint32, trail []db.AuditRecord) []uint32 {
+	list := []uint32{}
+
+	for _, card := range cards {
+		if !slices.ContainsFunc(trail, func(r db.AuditRecord) bool { return r.CardNumber == card && r.Status == "error" }) {
+			list = append(list, card)
+		}
+	}
+
+	return list
+}
+
+func purge2log(devices []uhppote.Device, trail []db.AuditRecord) []db.LogRecord {
+	now := time.Now()
+	recordset := []db.LogRecord{}
+
+	for _, device := range devices {
+		deleted := 0
+		errors := 0
+
+		for _, r := range trail {
+			if r.Controller == device.DeviceID && r.Status == "expired" {
+				deleted++
+			} else if r.Controller == device.DeviceID {
+				errors++
+			}
+		}
+
+		recordset = append(recordset, db.LogRecord{
+			Timestamp:  now,
+			Operation:  "purge-expired",
+			Controller: device.DeviceID,
+			Detail:     fmt.Sprintf("deleted:%-4v errors:%-4v", deleted, errors),
+			Counters: map[string]int{
+				"deleted": deleted,
+				"errors":  errors,
+			},
+		})
+	}
+
+	return recordset
+}
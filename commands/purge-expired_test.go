@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestPurgeExpiredWithMark(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		status string
+		err    bool
+	}{
+		{
+			name:   "status column",
+			schema: `CREATE TABLE ACL (CardNumber INTEGER, StartDate TEXT, EndDate TEXT, Status TEXT DEFAULT 'active');`,
+			status: "expired",
+			err:    false,
+		},
+		{
+			name:   "no status column",
+			schema: `CREATE TABLE ACL (CardNumber INTEGER, StartDate TEXT, EndDate TEXT);`,
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		dsn := filepath.Join(dir, "ACL.db")
+		conf := filepath.Join(dir, "uhppoted.conf")
+
+		if err := os.WriteFile(conf, []byte{}, 0600); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+			t.Fatalf("%v", err)
+		} else {
+			for _, s := range []string{test.schema, `INSERT INTO ACL (CardNumber, StartDate, EndDate) VALUES (10058400, '2020-01-01', '2020-12-31');`} {
+				if _, err := dbc.Exec(s); err != nil {
+					t.Fatalf("%v: %v", test.name, err)
+				}
+			}
+
+			dbc.Close()
+		}
+
+		cmd := PurgeExpiredCmd
+		cmd.dsn = "sqlite3://" + dsn
+		cmd.lockfile = filepath.Join(dir, "uhppoted-app-db.lock")
+		cmd.mark = "expired"
+
+		err := cmd.Execute(&Options{Config: conf})
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected error, got nil", test.name)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%v: unexpected error (%v)", test.name, err)
+		}
+
+		var status string
+		if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+			t.Fatalf("%v", err)
+		} else if err := dbc.QueryRow(`SELECT Status FROM ACL WHERE CardNumber=10058400`).Scan(&status); err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		} else {
+			dbc.Close()
+		}
+
+		if status != test.status {
+			t.Errorf("%v: incorrect card status - expected:%v, got:%v", test.name, test.status, status)
+		}
+	}
+}
+
+func TestPurged(t *testing.T) {
+	cards := []uint32{10058400, 10058401, 10058402}
+	trail := []db.AuditRecord{
+		{Controller: 405419896, CardNumber: 10058400, Status: "expired"},
+		{Controller: 405419896, CardNumber: 10058401, Status: "expired"},
+		{Controller: 303986753, CardNumber: 10058401, Status: "error"},
+	}
+
+	expected := []uint32{10058400, 10058402}
+
+	if purged := purged(cards, trail); !reflect.DeepEqual(purged, expected) {
+		t.Errorf("incorrect purged cards - expected:%v, got:%v", expected, purged)
+	}
+}
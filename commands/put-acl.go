@@ -1,10 +1,8 @@
 package commands
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -18,7 +16,7 @@ var PutACLCmd = PutACL{
 	command: command{
 		name:        "put-acl",
 		description: "Stores an access control list in a TSV file to a database",
-		usage:       "[--with-pin] --dsn <DSN> [--table:ACL <table>] [--table:log <table>] [--file <file>]",
+		usage:       "[--with-pin [--pin-key <file>]] [--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:log <table>] --file <file> [--format <format>] [--report <file>] [--skip-invalid]",
 
 		dsn: "",
 		tables: tables{
@@ -31,19 +29,29 @@ var PutACLCmd = PutACL{
 		debug:    false,
 	},
 
-	file: "",
+	file:        "",
+	format:      "",
+	report:      "",
+	skipInvalid: false,
 }
 
 type PutACL struct {
 	command
-	file string
+	file        string
+	format      string
+	report      string
+	skipInvalid bool
 }
 
 func (cmd *PutACL) Help() {
 	fmt.Println()
-	fmt.Printf("  Usage: %s [--debug] [--config <file>] put-acl [--with-pin] --file <file> --dsn <DSN> [--table:ACL <table>] [--table:log <table>]\n", APP)
+	fmt.Printf("  Usage: %s [--debug] [--config <file>] put-acl [--with-pin [--pin-key <file>]] --file <file> [--format <format>] [--report <file>] [--skip-invalid] [--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:log <table>]\n", APP)
 	fmt.Println()
-	fmt.Println("  Stores an access control list in a TSV file to a database")
+	fmt.Println("  Stores an access control list in a TSV, CSV, JSON or Excel (XLSX) file to a database")
+	fmt.Println()
+	fmt.Println("  The card numbers, dates, PINs and door columns are validated before updating the database and any invalid")
+	fmt.Println("  records are listed in a row-level error report. The database is only updated if all the records are valid,")
+	fmt.Println("  unless --skip-invalid is specified.")
 	fmt.Println()
 
 	helpOptions(cmd.FlagSet())
@@ -52,6 +60,7 @@ func (cmd *PutACL) Help() {
 	fmt.Println("  Examples:")
 	fmt.Println(`    uhppote-app-db --debug put-acl --with-pin --file "ACL.tsv" --dsn "sqlite3://./db/ACL.db"`)
 	fmt.Println(`    uhppote-app-db --debug put-acl --with-pin --file "ACL.tsv" --dsn "sqlite3://./db/ACL.db" --table:ACL ACL2  --table:Log OpsLog`)
+	fmt.Println(`    uhppote-app-db put-acl --file "HR.xlsx" --report "errors.tsv" --skip-invalid --dsn "sqlite3://./db/ACL.db"`)
 	fmt.Println()
 }
 
@@ -61,9 +70,17 @@ func (cmd *PutACL) FlagSet() *flag.FlagSet {
 	flagset.StringVar(&cmd.dsn, "dsn", cmd.dsn, "DSN for database")
 	flagset.StringVar(&cmd.tables.ACL, "table:ACL", cmd.tables.ACL, "ACL table name. Defaults to ACL")
 	flagset.StringVar(&cmd.tables.Log, "table:log", cmd.tables.Log, "Operations log table name. Defaults to ''")
-	flagset.StringVar(&cmd.file, "file", cmd.file, "Optional TSV filepath. Defaults to stdout")
+	flagset.StringVar(&cmd.file, "file", cmd.file, "TSV, CSV, JSON or XLSX filepath")
+	flagset.StringVar(&cmd.format, "format", cmd.format, "File format (tsv, csv, json or xlsx). Defaults to the file extension, falling back to tsv")
+	flagset.StringVar(&cmd.report, "report", cmd.report, "Optional TSV file for the row-level validation error report. Defaults to stdout")
+	flagset.BoolVar(&cmd.skipInvalid, "skip-invalid", cmd.skipInvalid, "Stores the valid records even if the file contains invalid records")
 	flagset.BoolVar(&cmd.withPIN, "with-pin", cmd.withPIN, "Include card keypad PIN code in retrieved ACL information")
+	flagset.StringVar(&cmd.pinKey, "pin-key", cmd.pinKey, "File with the AES-256 key used to encrypt the card keypad PINs stored in the DB")
+	flagset.StringVar(&cmd.columns, "columns", cmd.columns, "Optional file with the ACL table column mapping. Defaults to the mapping in the configuration file")
 	flagset.StringVar(&cmd.lockfile, "lockfile", cmd.lockfile, "Filepath for lock file. Defaults to <tmp>/uhppoted-app-db.lock")
+	flagset.StringVar(&cmd.profile, "profile", cmd.profile, "Optional named profile from the configuration file with the DSN, tables and options")
+
+	cmd.flagset = flagset
 
 	return flagset
 }
@@ -71,16 +88,18 @@ func (cmd *PutACL) FlagSet() *flag.FlagSet {
 func (cmd *PutACL) Execute(args ...any) error {
 	options := args[0].(*Options)
 
+	cmd.started = time.Now()
+
 	cmd.config = options.Config
 	cmd.debug = options.Debug
 
 	// ... check parameters
 	if strings.TrimSpace(cmd.file) == "" {
-		return fmt.Errorf("missing TSV file")
+		return fmt.Errorf("missing ACL file")
 	}
 
-	if strings.TrimSpace(cmd.dsn) == "" {
-		return fmt.Errorf("missing database DSN")
+	if err := cmd.resolve(); err != nil {
+		return err
 	}
 
 	if strings.TrimSpace(cmd.tables.ACL) == "" {
@@ -92,7 +111,7 @@ func (cmd *PutACL) Execute(args ...any) error {
 		return err
 	} else {
 		defer func() {
-			infof("put-acl", "removing lockfile")
+			cmd.infof("put-acl", "removing lockfile")
 			kraken.Release()
 		}()
 	}
@@ -103,20 +122,34 @@ func (cmd *PutACL) Execute(args ...any) error {
 		return fmt.Errorf("could not load configuration (%v)", err)
 	}
 
-	_, devices := getDevices(conf, cmd.debug)
+	_, devices := getDevices(conf, cmd.timeout, cmd.debug)
+
+	// ... get ACL table column mapping
+	columns, err := cmd.getColumns()
+	if err != nil {
+		return err
+	}
 
-	// ... retrieve ACL from TSV file
-	if acl, warnings, err := cmd.getACL(devices); err != nil {
+	// ... get PIN key
+	key, err := cmd.loadPINKey()
+	if err != nil {
+		return err
+	}
+
+	// ... retrieve ACL from file
+	if acl, warnings, err := cmd.getACL(devices, columns); err != nil {
 		return err
 	} else {
 		for _, w := range warnings {
-			warnf("put-acl", "%v", w.Error())
+			cmd.warnf("put-acl", "%v", w.Error())
 		}
 
-		if err := putACL(cmd.dsn, cmd.tables.ACL, acl, cmd.withPIN); err != nil {
+		if err := encryptPINs(&acl, key); err != nil {
+			return err
+		} else if err := putACL(cmd.dsn, cmd.tls, cmd.tables.ACL, acl, columns, cmd.withPIN); err != nil {
 			return err
 		} else {
-			infof("put-acl", "Updated DB ACL table from %v", cmd.file)
+			cmd.infof("put-acl", "Updated DB ACL table from %v", cmd.file)
 
 			if cmd.tables.Log != "" {
 				recordset := []db.LogRecord{
@@ -124,10 +157,13 @@ func (cmd *PutACL) Execute(args ...any) error {
 						Timestamp: time.Now(),
 						Operation: "put-acl",
 						Detail:    fmt.Sprintf("records:%v", len(acl.Records)),
+						Counters: map[string]int{
+							"records": len(acl.Records),
+						},
 					},
 				}
 
-				if err := stashToLog(cmd.dsn, cmd.tables.Log, recordset); err != nil {
+				if err := cmd.stashToLog(elapsed(recordset, cmd.started)); err != nil {
 					return err
 				}
 			}
@@ -137,47 +173,30 @@ func (cmd *PutACL) Execute(args ...any) error {
 	return nil
 }
 
-func (cmd *PutACL) getACL(devices []uhppote.Device) (lib.Table, []error, error) {
-	if f, err := os.Open(cmd.file); err != nil {
+func (cmd *PutACL) getACL(devices []uhppote.Device, columns db.Columns) (lib.Table, []error, error) {
+	table, err := readTable(cmd.file, cmd.format)
+	if err != nil {
 		return lib.Table{}, nil, err
-	} else {
-		defer f.Close()
-
-		r := csv.NewReader(f)
-		r.Comma = '\t'
-
-		records, err := r.ReadAll()
-		if err != nil {
-			return lib.Table{}, nil, err
-		} else if len(records) == 0 {
-			return lib.Table{}, nil, fmt.Errorf("TSV file is empty")
-		} else if len(records) < 1 {
-			return lib.Table{}, nil, fmt.Errorf("TSV file missing header")
-		}
-
-		// ... header
-		header := make([]string, len(records[0]))
-
-		for i, v := range records[0] {
-			header[i] = fmt.Sprintf("%v", v)
-		}
+	}
 
-		// ... records
-		rows := make([][]string, 0)
+	valid, errors, err := validateACL(table, devices, columns, cmd.withPIN)
+	if err != nil {
+		return lib.Table{}, nil, err
+	}
 
-		for _, record := range records[1:] {
-			row := make([]string, len(record))
+	if len(errors) == 0 {
+		return valid, nil, nil
+	}
 
-			for i, v := range record {
-				row[i] = fmt.Sprintf("%v", v)
-			}
+	if err := writeReport(cmd.report, errors); err != nil {
+		return lib.Table{}, nil, err
+	}
 
-			rows = append(rows, row)
-		}
+	invalid := len(table.Records) - len(valid.Records)
 
-		return lib.Table{
-			Header:  header,
-			Records: rows,
-		}, nil, nil
+	if !cmd.skipInvalid {
+		return lib.Table{}, nil, fmt.Errorf("%v has %v invalid records", cmd.file, invalid)
 	}
+
+	return valid, []error{fmt.Errorf("skipped %v invalid records in %v", invalid, cmd.file)}, nil
 }
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+)
+
+// scopes removes the optional controller scope column from an ACL table, returning the list of
+// controllers to which each scoped card is restricted. Cards with a blank scope are not restricted.
+func scopes(table lib.Table) (lib.Table, map[uint32][]uint32, error) {
+	scoped := map[uint32][]uint32{}
+	cardnumber := -1
+	scope := -1
+
+	for i, h := range table.Header {
+		switch normalise(h) {
+		case "cardnumber":
+			cardnumber = i
+		case "controllers":
+			scope = i
+		}
+	}
+
+	if scope < 0 {
+		return table, scoped, nil
+	} else if cardnumber < 0 {
+		return table, scoped, fmt.Errorf("missing 'card number' column")
+	}
+
+	header := append(append([]string{}, table.Header[:scope]...), table.Header[scope+1:]...)
+	records := [][]string{}
+
+	for _, record := range table.Records {
+		row := append([]string{}, record[:min(scope, len(record))]...)
+		if scope < len(record) {
+			row = append(row, record[scope+1:]...)
+		}
+
+		records = append(records, row)
+
+		if scope >= len(record) || strings.TrimSpace(record[scope]) == "" {
+			continue
+		}
+
+		card, err := strconv.ParseUint(record[cardnumber], 10, 32)
+		if err != nil {
+			warnf("acl", "invalid card number (%v)", record[cardnumber])
+			continue
+		}
+
+		controllers := []uint32{}
+		for v := range strings.SplitSeq(record[scope], ",") {
+			if controller, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32); err != nil || controller == 0 {
+				warnf("acl", "card %v: invalid controller (%v)", card, strings.TrimSpace(v))
+			} else {
+				controllers = append(controllers, uint32(controller))
+			}
+		}
+
+		scoped[uint32(card)] = controllers
+	}
+
+	return lib.Table{
+		Header:  header,
+		Records: records,
+	}, scoped, nil
+}
+
+// restrict removes scoped cards from the ACL for any controller that is not in the card's scope.
+func restrict(acl lib.ACL, scoped map[uint32][]uint32) {
+	for controller, cards := range acl {
+		for card := range cards {
+			if controllers, ok := scoped[card]; ok && !slices.Contains(controllers, controller) {
+				debugf("acl", "%v  card %v is out of scope", controller, card)
+				delete(cards, card)
+			}
+		}
+	}
+}
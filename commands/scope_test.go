@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	core "github.com/uhppoted/uhppote-core/types"
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+)
+
+func TestScopes(t *testing.T) {
+	table := lib.Table{
+		Header: []string{"Card Number", "From", "To", "Controllers", "Great Hall"},
+		Records: [][]string{
+			{"10058400", "2025-01-01", "2025-12-31", "", "Y"},
+			{"10058401", "2025-01-01", "2025-12-31", " 405419896, 303986753 ", "N"},
+			{"10058402", "2025-01-01", "2025-12-31", "x", "Y"},
+		},
+	}
+
+	expected := lib.Table{
+		Header: []string{"Card Number", "From", "To", "Great Hall"},
+		Records: [][]string{
+			{"10058400", "2025-01-01", "2025-12-31", "Y"},
+			{"10058401", "2025-01-01", "2025-12-31", "N"},
+			{"10058402", "2025-01-01", "2025-12-31", "Y"},
+		},
+	}
+
+	scoped := map[uint32][]uint32{
+		10058401: {405419896, 303986753},
+		10058402: {},
+	}
+
+	if unscoped, controllers, err := scopes(table); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if !reflect.DeepEqual(unscoped, expected) {
+		t.Errorf("incorrect ACL table\n   expected:%q\n   got:     %q", expected, unscoped)
+	} else if !reflect.DeepEqual(controllers, scoped) {
+		t.Errorf("incorrect scoped cards\n   expected:%v\n   got:     %v", scoped, controllers)
+	}
+}
+
+func TestRestrict(t *testing.T) {
+	card := func(n uint32) core.Card {
+		return core.Card{CardNumber: n}
+	}
+
+	acl := lib.ACL{
+		405419896: {10058400: card(10058400), 10058401: card(10058401), 10058402: card(10058402)},
+		303986753: {10058400: card(10058400), 10058401: card(10058401), 10058402: card(10058402)},
+	}
+
+	scoped := map[uint32][]uint32{
+		10058401: {405419896},
+		10058402: {},
+	}
+
+	expected := lib.ACL{
+		405419896: {10058400: card(10058400), 10058401: card(10058401)},
+		303986753: {10058400: card(10058400)},
+	}
+
+	restrict(acl, scoped)
+
+	if !reflect.DeepEqual(acl, expected) {
+		t.Errorf("incorrect restricted ACL\n   expected:%v\n   got:     %v", expected, acl)
+	}
+}
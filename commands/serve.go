@@ -0,0 +1,480 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/uhppoted/uhppoted-lib/config"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+	"github.com/uhppoted/uhppoted-app-db/log"
+	"github.com/uhppoted/uhppoted-app-db/metrics"
+)
+
+var ServeCmd = Serve{
+	command: command{
+		name:        "serve",
+		description: "Runs an HTTP server with a REST API for the ACL, events, audit trail and operations log",
+		usage:       "--dsn <DSN> --tokens <file> [--bind <address>] [--tls-certificate <file> --tls-key <file>] [--table:ACL <table>] [--table:events <table>] [--table:audit <table>] [--table:log <table>]",
+
+		dsn: "",
+		tables: tables{
+			ACL:    "ACL",
+			Events: "Events",
+			Audit:  "",
+			Log:    "",
+		},
+		withPIN:  false,
+		lockfile: "",
+		config:   config.DefaultConfig,
+		debug:    false,
+	},
+	bind:  "127.0.0.1:8080",
+	limit: 100,
+}
+
+type Serve struct {
+	command
+	bind        string
+	tokens      string
+	certificate string
+	key         string
+	limit       int
+}
+
+func (cmd *Serve) Help() {
+	fmt.Println()
+	fmt.Printf("  Usage: %s [--debug] [--config <file>] serve --dsn <DSN> --tokens <file> [--bind <address>] [--tls-certificate <file> --tls-key <file>] [--table:ACL <table>] [--table:events <table>] [--table:audit <table>] [--table:log <table>]\n", APP)
+	fmt.Println()
+	fmt.Println("  Runs an HTTP server with a REST API for the ACL, events, audit trail and operations log")
+	fmt.Println()
+
+	helpOptions(cmd.FlagSet())
+
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println(`    uhppote-app-db serve --dsn "sqlite3://./db/ACL.db" --tokens ./tokens`)
+	fmt.Println(`    uhppote-app-db serve --dsn "sqlite3://./db/ACL.db" --tokens ./tokens --bind 0.0.0.0:8443 --tls-certificate server.cert --tls-key server.key --table:audit Audit --table:log OpsLog`)
+	fmt.Println()
+}
+
+func (cmd *Serve) FlagSet() *flag.FlagSet {
+	flagset := flag.NewFlagSet("serve", flag.ExitOnError)
+
+	flagset.StringVar(&cmd.dsn, "dsn", cmd.dsn, "DSN for database")
+	flagset.StringVar(&cmd.bind, "bind", cmd.bind, "HTTP server address. Defaults to 127.0.0.1:8080")
+	flagset.StringVar(&cmd.tokens, "tokens", cmd.tokens, "File with the list of valid API bearer tokens (one per line)")
+	flagset.StringVar(&cmd.certificate, "tls-certificate", cmd.certificate, "Optional TLS server certificate file")
+	flagset.StringVar(&cmd.key, "tls-key", cmd.key, "Optional TLS server key file")
+	flagset.StringVar(&cmd.tables.ACL, "table:ACL", cmd.tables.ACL, "ACL table name. Defaults to ACL")
+	flagset.StringVar(&cmd.tables.Events, "table:events", cmd.tables.Events, "Events table name. Defaults to Events")
+	flagset.StringVar(&cmd.tables.Audit, "table:audit", cmd.tables.Audit, "Audit trail table name. Defaults to ''")
+	flagset.StringVar(&cmd.tables.Log, "table:log", cmd.tables.Log, "Operations log table name. Defaults to ''")
+	flagset.StringVar(&cmd.tables.Groups, "table:groups", cmd.tables.Groups, "Optional access groups table name. Defaults to ''")
+	flagset.StringVar(&cmd.tables.Members, "table:members", cmd.tables.Members, "Optional access group members table name. Defaults to ''")
+	flagset.StringVar(&cmd.tables.Profiles, "table:profiles", cmd.tables.Profiles, "Optional time profiles table name used to validate the time profiles in the ACL. Defaults to ''")
+	flagset.StringVar(&cmd.columns, "columns", cmd.columns, "Optional file with the ACL table column mapping. Defaults to the mapping in the configuration file")
+	flagset.BoolVar(&cmd.withPIN, "with-pin", cmd.withPIN, "Include card keypad PIN code in the ACL and when updating or comparing access controllers")
+	flagset.StringVar(&cmd.pinKey, "pin-key", cmd.pinKey, "File with the AES-256 key used to encrypt the card keypad PINs stored in the DB")
+	flagset.IntVar(&cmd.limit, "limit", cmd.limit, "Default maximum number of records returned by the events, audit and log queries. Defaults to 100")
+	flagset.StringVar(&cmd.lockfile, "lockfile", cmd.lockfile, "Filepath for the load-acl and compare-acl lock file. Defaults to <tmp>/uhppoted-app-db.lock")
+	flagset.StringVar(&cmd.profile, "profile", cmd.profile, "Optional named profile from the configuration file with the DSN, tables and options")
+
+	cmd.flagset = flagset
+
+	return flagset
+}
+
+func (cmd *Serve) Execute(args ...any) error {
+	options := args[0].(*Options)
+
+	cmd.config = options.Config
+	cmd.debug = options.Debug
+
+	// ... check parameters
+	if err := cmd.resolve(); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(cmd.tokens) == "" {
+		return fmt.Errorf("missing API tokens file")
+	}
+
+	if (cmd.certificate == "") != (cmd.key == "") {
+		return fmt.Errorf("TLS requires both a certificate and a key")
+	}
+
+	tokens, err := loadTokens(cmd.tokens)
+	if err != nil {
+		return err
+	} else if len(tokens) == 0 {
+		return fmt.Errorf("no API tokens in %v", cmd.tokens)
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /acl", cmd.getACL)
+	mux.HandleFunc("GET /events", cmd.query(cmd.tables.Events))
+	mux.HandleFunc("GET /audit", cmd.query(cmd.tables.Audit))
+	mux.HandleFunc("GET /log", cmd.query(cmd.tables.Log))
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("POST /get-events", cmd.getEvents)
+	mux.HandleFunc("POST /load-acl", cmd.loadACL)
+	mux.HandleFunc("POST /compare-acl", cmd.compareACL)
+
+	server := http.Server{
+		Addr:              cmd.bind,
+		Handler:           authorise(tokens, mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-interrupt
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		cmd.infof("serve", "shutting down")
+		server.Shutdown(ctx)
+	}()
+
+	if cmd.certificate != "" {
+		cmd.infof("serve", "listening on https://%v", cmd.bind)
+		err = server.ListenAndServeTLS(cmd.certificate, cmd.key)
+	} else {
+		cmd.infof("serve", "listening on http://%v", cmd.bind)
+		err = server.ListenAndServe()
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// getACL returns the ACL from the DB as either JSON or TSV, with access groups expanded as for get-acl.
+// PINs are masked unless requested with the 'with-pin' query parameter (which requires serve --with-pin).
+func (cmd *Serve) getACL(w http.ResponseWriter, r *http.Request) {
+	withPIN := r.URL.Query().Get("with-pin") == "true"
+	if withPIN && !cmd.withPIN {
+		reply(w, http.StatusBadRequest, fmt.Errorf("with-pin requires serve --with-pin"))
+		return
+	}
+
+	columns, err := cmd.getColumns()
+	if err != nil {
+		cmd.internalError(w, r, err)
+		return
+	}
+
+	table, err := getACL(cmd.dsn, cmd.tls, cmd.tables.ACL, columns, cmd.withPIN)
+	if err == nil {
+		table, err = expand(cmd.dsn, cmd.tls, cmd.tables, columns, table)
+	}
+
+	if err == nil && withPIN {
+		var key cipher.AEAD
+		if key, err = cmd.loadPINKey(); err == nil {
+			err = decryptPINs(&table, key)
+		}
+	} else if err == nil {
+		maskTablePINs(&table)
+	}
+
+	if err != nil {
+		cmd.internalError(w, r, err)
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		reply(w, http.StatusOK, struct {
+			Header  []string   `json:"header"`
+			Records [][]string `json:"records"`
+		}{
+			Header:  table.Header,
+			Records: table.Records,
+		})
+
+	case "tsv":
+		var b bytes.Buffer
+		if err := table.ToTSV(&b); err != nil {
+			cmd.internalError(w, r, err)
+		} else {
+			w.Header().Set("Content-Type", "text/tab-separated-values")
+			w.Write(b.Bytes())
+		}
+
+	default:
+		reply(w, http.StatusBadRequest, fmt.Errorf("invalid format (%v)", r.URL.Query().Get("format")))
+	}
+}
+
+// query returns the records from the events, audit trail or operations log table, filtered by the
+// optional controller, card, from, to and limit query parameters.
+func (cmd *Serve) query(table string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if table == "" {
+			reply(w, http.StatusNotFound, fmt.Errorf("table not configured"))
+			return
+		}
+
+		filter, err := cmd.filter(r)
+		if err != nil {
+			reply(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if records, err := query(cmd.dsn, cmd.tls, table, filter); err != nil {
+			cmd.internalError(w, r, err)
+		} else {
+			reply(w, http.StatusOK, records)
+		}
+	}
+}
+
+func (cmd *Serve) filter(r *http.Request) (db.Filter, error) {
+	filter := db.Filter{
+		Where: map[string]any{},
+		Limit: cmd.limit,
+	}
+
+	q := r.URL.Query()
+
+	if v := q.Get("controller"); v != "" {
+		if controller, err := strconv.ParseUint(v, 10, 32); err != nil {
+			return filter, fmt.Errorf("invalid controller (%v)", v)
+		} else {
+			filter.Where["Controller"] = uint32(controller)
+		}
+	}
+
+	if v := q.Get("card"); v != "" {
+		if card, err := strconv.ParseUint(v, 10, 32); err != nil {
+			return filter, fmt.Errorf("invalid card number (%v)", v)
+		} else {
+			filter.Where["CardNumber"] = uint32(card)
+		}
+	}
+
+	timestamp := func(v string) (time.Time, error) {
+		if t, err := time.ParseInLocation("2006-01-02 15:04:05", v, time.Local); err == nil {
+			return t, nil
+		}
+
+		return time.ParseInLocation("2006-01-02", v, time.Local)
+	}
+
+	if v := q.Get("from"); v != "" {
+		if t, err := timestamp(v); err != nil {
+			return filter, fmt.Errorf("invalid 'from' date/time (%v)", v)
+		} else {
+			filter.From = t
+		}
+	}
+
+	if v := q.Get("to"); v != "" {
+		if t, err := timestamp(v); err != nil {
+			return filter, fmt.Errorf("invalid 'to' date/time (%v)", v)
+		} else {
+			filter.To = t
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if limit, err := strconv.Atoi(v); err != nil || limit < 0 {
+			return filter, fmt.Errorf("invalid limit (%v)", v)
+		} else {
+			filter.Limit = limit
+		}
+	}
+
+	return filter, nil
+}
+
+// getEvents runs get-events with the server DSN and tables, using the same lockfile as the get-events
+// command.
+func (cmd *Serve) getEvents(w http.ResponseWriter, r *http.Request) {
+	runID := newRun("get-events")
+	get := GetEventsCmd
+
+	get.runID = runID
+	get.dsn = cmd.dsn
+	get.resolved = true
+	get.tables = cmd.tables
+	get.lockfile = cmd.lockfile
+	get.timeout = cmd.timeout
+	get.tls = cmd.tls
+
+	if err := get.Execute(&Options{Config: cmd.config, Debug: cmd.debug}); err != nil {
+		cmd.internalError(w, r, err)
+	} else {
+		reply(w, http.StatusOK, struct {
+			Status string `json:"status"`
+			RunID  string `json:"run-id"`
+		}{
+			Status: "ok",
+			RunID:  runID,
+		})
+	}
+}
+
+// loadACL runs load-acl with the server DSN and tables, using the same lockfile as the load-acl
+// command.
+func (cmd *Serve) loadACL(w http.ResponseWriter, r *http.Request) {
+	runID := newRun("load-acl")
+	load := LoadACLCmd
+
+	load.runID = runID
+	load.dsn = cmd.dsn
+	load.resolved = true
+	load.tables = cmd.tables
+	load.withPIN = cmd.withPIN
+	load.pinKey = cmd.pinKey
+	load.columns = cmd.columns
+	load.lockfile = cmd.lockfile
+	load.timeout = cmd.timeout
+	load.tls = cmd.tls
+
+	if err := load.Execute(&Options{Config: cmd.config, Debug: cmd.debug}); err != nil {
+		cmd.internalError(w, r, err)
+	} else {
+		reply(w, http.StatusOK, struct {
+			Status string `json:"status"`
+			RunID  string `json:"run-id"`
+		}{
+			Status: "ok",
+			RunID:  runID,
+		})
+	}
+}
+
+// compareACL runs compare-acl with the server DSN and tables and returns the JSON compare report.
+// The corrections are applied to the controllers if the 'fix' query parameter is 'true'.
+func (cmd *Serve) compareACL(w http.ResponseWriter, r *http.Request) {
+	dir, err := os.MkdirTemp("", "uhppoted-app-db")
+	if err != nil {
+		cmd.internalError(w, r, err)
+		return
+	}
+
+	defer os.RemoveAll(dir)
+
+	runID := newRun("compare-acl")
+	compare := CompareACLCmd
+
+	compare.runID = runID
+	compare.dsn = cmd.dsn
+	compare.resolved = true
+	compare.tables = cmd.tables
+	compare.withPIN = cmd.withPIN
+	compare.pinKey = cmd.pinKey
+	compare.columns = cmd.columns
+	compare.lockfile = cmd.lockfile
+	compare.timeout = cmd.timeout
+	compare.tls = cmd.tls
+	compare.reportFormat = "json"
+	compare.file = filepath.Join(dir, "compare.json")
+	compare.fix = r.URL.Query().Get("fix") == "true"
+
+	var exit ExitError
+
+	if err := compare.Execute(&Options{Config: cmd.config, Debug: cmd.debug}); err != nil && !(errors.As(err, &exit) && exit.Err == nil) {
+		cmd.internalError(w, r, err)
+	} else if report, err := os.ReadFile(compare.file); err != nil {
+		cmd.internalError(w, r, err)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Run-ID", runID)
+		w.Write(report)
+	}
+}
+
+// internalError logs the error and replies with a generic error, so that the DB and controller error
+// details (e.g. table names, hosts and SQL errors) are not returned to the API client.
+func (cmd *Serve) internalError(w http.ResponseWriter, r *http.Request, err error) {
+	cmd.errorf("serve", "%v %v  %v", r.Method, r.URL.Path, err)
+	reply(w, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+}
+
+// newRun returns a new run ID for a triggered run so that the log lines and the audit trail and operations
+// log records for the run can be linked.
+func newRun(operation string) string {
+	runID := log.NewRunID()
+
+	infof("serve", "%v run %v", operation, runID)
+
+	return runID
+}
+
+func authorise(tokens [][]byte, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			for _, t := range tokens {
+				if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), t) == 1 {
+					h.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+
+		warnf("serve", "unauthorised request %v %v from %v", r.Method, r.URL.Path, r.RemoteAddr)
+		reply(w, http.StatusUnauthorized, fmt.Errorf("unauthorised"))
+	})
+}
+
+func loadTokens(file string) ([][]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	tokens := [][]byte{}
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		if token := strings.TrimSpace(scanner.Text()); token != "" && !strings.HasPrefix(token, "#") {
+			tokens = append(tokens, []byte(token))
+		}
+	}
+
+	return tokens, scanner.Err()
+}
+
+func reply(w http.ResponseWriter, status int, response any) {
+	if err, ok := response.(error); ok {
+		response = struct {
+			Error string `json:"error"`
+		}{
+			Error: err.Error(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		warnf("serve", "%v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestServeFilter(t *testing.T) {
+	cmd := Serve{limit: 100}
+
+	tests := []struct {
+		query    string
+		expected db.Filter
+		err      bool
+	}{
+		{"", db.Filter{Where: map[string]any{}, Limit: 100}, false},
+		{"controller=405419896&card=10058400", db.Filter{Where: map[string]any{"Controller": uint32(405419896), "CardNumber": uint32(10058400)}, Limit: 100}, false},
+		{
+			"from=2025-06-01&to=2025-06-15+12:30:45&limit=0",
+			db.Filter{
+				Where: map[string]any{},
+				From:  time.Date(2025, time.June, 1, 0, 0, 0, 0, time.Local),
+				To:    time.Date(2025, time.June, 15, 12, 30, 45, 0, time.Local),
+				Limit: 0,
+			},
+			false,
+		},
+		{"controller=x", db.Filter{}, true},
+		{"from=yesterday", db.Filter{}, true},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/events?"+test.query, nil)
+
+		filter, err := cmd.filter(r)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error, got %+v", test.query, filter)
+			}
+		} else if err != nil {
+			t.Errorf("%q: unexpected error (%v)", test.query, err)
+		} else if !reflect.DeepEqual(filter, test.expected) {
+			t.Errorf("%q: incorrect filter\n   expected:%+v\n   got:     %+v", test.query, test.expected, filter)
+		}
+	}
+}
+
+func TestServeGetACL(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "acl.db")
+
+	if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+		t.Fatalf("%v", err)
+	} else if _, err := dbc.Exec(`CREATE TABLE ACL (CardNumber INTEGER, StartDate TEXT, EndDate TEXT, GreatHall INTEGER, Status TEXT);
+	INSERT INTO ACL VALUES (10058400, '2025-01-01', '2025-12-31', 1, 'active');
+	INSERT INTO ACL VALUES (10058401, '2025-01-01', '2025-12-31', 1, 'lost');`); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		dbc.Close()
+	}
+
+	cmd := Serve{
+		command: command{
+			dsn:    "sqlite3://" + dsn,
+			tables: tables{ACL: "ACL"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected string
+	}{
+		{"JSON", "", http.StatusOK, `{"header":["Card Number","From","To","GreatHall","Status"],"records":[["10058400","2025-01-01","2025-12-31","Y","active"],["10058401","2025-01-01","2025-12-31","Y","lost"]]}`},
+		{"invalid format", "format=xml", http.StatusBadRequest, `{"error":"invalid format (xml)"}`},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/acl?"+test.query, nil)
+
+		cmd.getACL(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%v: incorrect HTTP status - expected:%v, got:%v (%v)", test.name, test.status, w.Code, w.Body.String())
+		} else if body := strings.TrimSpace(w.Body.String()); body != strings.TrimSpace(test.expected) {
+			t.Errorf("%v: incorrect response\n   expected:%q\n   got:     %q", test.name, test.expected, body)
+		}
+	}
+}
+
+func TestServeGetACLWithPIN(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "acl.db")
+
+	if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+		t.Fatalf("%v", err)
+	} else if _, err := dbc.Exec(`CREATE TABLE ACL (CardNumber INTEGER, PIN INTEGER, StartDate TEXT, EndDate TEXT, GreatHall INTEGER);
+	INSERT INTO ACL VALUES (10058400, 7531, '2025-01-01', '2025-12-31', 1);`); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		dbc.Close()
+	}
+
+	tests := []struct {
+		name     string
+		table    string
+		query    string
+		status   int
+		expected string
+	}{
+		{"masked", "ACL", "", http.StatusOK, `{"header":["Card Number","PIN","From","To","GreatHall"],"records":[["10058400","****","2025-01-01","2025-12-31","Y"]]}`},
+		{"with PIN", "ACL", "with-pin=true", http.StatusOK, `{"header":["Card Number","PIN","From","To","GreatHall"],"records":[["10058400","7531","2025-01-01","2025-12-31","Y"]]}`},
+		{"DB error", "ACL2", "", http.StatusInternalServerError, `{"error":"internal server error"}`},
+	}
+
+	for _, test := range tests {
+		cmd := Serve{
+			command: command{
+				dsn:     "sqlite3://" + dsn,
+				tables:  tables{ACL: test.table},
+				withPIN: true,
+			},
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/acl?"+test.query, nil)
+
+		cmd.getACL(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%v: incorrect HTTP status - expected:%v, got:%v (%v)", test.name, test.status, w.Code, w.Body.String())
+		} else if body := strings.TrimSpace(w.Body.String()); body != test.expected {
+			t.Errorf("%v: incorrect response\n   expected:%q\n   got:     %q", test.name, test.expected, body)
+		}
+	}
+}
+
+func TestAuthorise(t *testing.T) {
+	tokens := [][]byte{[]byte("qwerty"), []byte("uiop")}
+	handler := authorise(tokens, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{"valid token", "Bearer qwerty", http.StatusNoContent},
+		{"invalid token", "Bearer asdf", http.StatusUnauthorized},
+		{"not bearer", "Basic qwerty", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/acl", nil)
+
+		if test.authorization != "" {
+			r.Header.Set("Authorization", test.authorization)
+		}
+
+		handler.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%v: incorrect HTTP status - expected:%v, got:%v", test.name, test.status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+)
+
+// activeCards removes the optional card status column from an ACL table along with any cards that
+// are not 'active', returning the status of the removed cards so that they can be recorded in the
+// audit trail when they are deleted from the controllers.
+func activeCards(table lib.Table) (lib.Table, map[uint32]string, error) {
+	revoked := map[uint32]string{}
+	cardnumber := -1
+	status := -1
+
+	for i, h := range table.Header {
+		switch normalise(h) {
+		case "cardnumber":
+			cardnumber = i
+		case "status":
+			status = i
+		}
+	}
+
+	if status < 0 {
+		return table, revoked, nil
+	} else if cardnumber < 0 {
+		return table, revoked, fmt.Errorf("missing 'card number' column")
+	}
+
+	header := append(append([]string{}, table.Header[:status]...), table.Header[status+1:]...)
+	records := [][]string{}
+
+	for _, record := range table.Records {
+		if status >= len(record) || record[status] == "" || record[status] == "active" {
+			row := append([]string{}, record[:min(status, len(record))]...)
+			if status < len(record) {
+				row = append(row, record[status+1:]...)
+			}
+
+			records = append(records, row)
+		} else if card, err := strconv.ParseUint(record[cardnumber], 10, 32); err != nil {
+			warnf("acl", "invalid card number (%v)", record[cardnumber])
+		} else {
+			debugf("acl", "card %v is %v", card, record[status])
+			revoked[uint32(card)] = record[status]
+		}
+	}
+
+	return lib.Table{
+		Header:  header,
+		Records: records,
+	}, revoked, nil
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+)
+
+func TestActiveCards(t *testing.T) {
+	table := lib.Table{
+		Header: []string{"Card Number", "From", "To", "Status", "Great Hall"},
+		Records: [][]string{
+			{"10058400", "2025-01-01", "2025-12-31", "active", "Y"},
+			{"10058401", "2025-01-01", "2025-12-31", "", "N"},
+			{"10058402", "2025-01-01", "2025-12-31", "lost", "Y"},
+			{"10058403", "2025-01-01", "2025-12-31", "suspended", "29"},
+		},
+	}
+
+	expected := lib.Table{
+		Header: []string{"Card Number", "From", "To", "Great Hall"},
+		Records: [][]string{
+			{"10058400", "2025-01-01", "2025-12-31", "Y"},
+			{"10058401", "2025-01-01", "2025-12-31", "N"},
+		},
+	}
+
+	revoked := map[uint32]string{
+		10058402: "lost",
+		10058403: "suspended",
+	}
+
+	if active, inactive, err := activeCards(table); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if !reflect.DeepEqual(active, expected) {
+		t.Errorf("incorrect ACL table\n   expected:%q\n   got:     %q", expected, active)
+	} else if !reflect.DeepEqual(inactive, revoked) {
+		t.Errorf("incorrect revoked cards\n   expected:%v\n   got:     %v", revoked, inactive)
+	}
+}
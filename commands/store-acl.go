@@ -3,7 +3,6 @@ package commands
 import (
 	"flag"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/uhppoted/uhppote-core/uhppote"
@@ -16,7 +15,7 @@ var StoreACLCmd = StoreACL{
 	command: command{
 		name:        "store-acl",
 		description: "Retrieves the ACL from a set of access controllers and stores it in a database table",
-		usage:       "--with-pin --dsn <DSN> [--table:ACL <table>] [--table:log <table>]",
+		usage:       "--with-pin [--pin-key <file>] [--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:log <table>]",
 
 		dsn: "",
 		tables: tables{
@@ -36,7 +35,7 @@ type StoreACL struct {
 
 func (cmd *StoreACL) Help() {
 	fmt.Println()
-	fmt.Printf("  Usage: %s [--debug] [--config <file>] store-acl [--with-pin] --dsn <DSN> [--table:ACL <table>] [--table:log <table>]\n", APP)
+	fmt.Printf("  Usage: %s [--debug] [--config <file>] store-acl [--with-pin [--pin-key <file>]] [--columns <file>] --dsn <DSN> [--table:ACL <table>] [--table:log <table>]\n", APP)
 	fmt.Println()
 	fmt.Println("  Retrieves the ACL from a set of access controllers and stores it in a database table")
 	fmt.Println()
@@ -57,7 +56,12 @@ func (cmd *StoreACL) FlagSet() *flag.FlagSet {
 	flagset.StringVar(&cmd.tables.ACL, "table:ACL", cmd.tables.ACL, "ACL table name. Defaults to ACL")
 	flagset.StringVar(&cmd.tables.Log, "table:log", cmd.tables.Log, "Operations log table name. Defaults to ''")
 	flagset.BoolVar(&cmd.withPIN, "with-pin", cmd.withPIN, "Include card keypad PIN code in retrieved ACL information")
+	flagset.StringVar(&cmd.pinKey, "pin-key", cmd.pinKey, "File with the AES-256 key used to encrypt the card keypad PINs stored in the DB")
+	flagset.StringVar(&cmd.columns, "columns", cmd.columns, "Optional file with the ACL table column mapping. Defaults to the mapping in the configuration file")
 	flagset.StringVar(&cmd.lockfile, "lockfile", cmd.lockfile, "Filepath for lock file. Defaults to <tmp>/uhppoted-app-db.lock")
+	flagset.StringVar(&cmd.profile, "profile", cmd.profile, "Optional named profile from the configuration file with the DSN, tables and options")
+
+	cmd.flagset = flagset
 
 	return flagset
 }
@@ -65,12 +69,14 @@ func (cmd *StoreACL) FlagSet() *flag.FlagSet {
 func (cmd *StoreACL) Execute(args ...any) error {
 	options := args[0].(*Options)
 
+	cmd.started = time.Now()
+
 	cmd.config = options.Config
 	cmd.debug = options.Debug
 
 	// ... check parameters
-	if strings.TrimSpace(cmd.dsn) == "" {
-		return fmt.Errorf("missing database DSN")
+	if err := cmd.resolve(); err != nil {
+		return err
 	}
 
 	// ... locked?
@@ -78,7 +84,7 @@ func (cmd *StoreACL) Execute(args ...any) error {
 		return err
 	} else {
 		defer func() {
-			infof("store-acl", "removing lockfile")
+			cmd.infof("store-acl", "removing lockfile")
 			kraken.Release()
 		}()
 	}
@@ -89,17 +95,31 @@ func (cmd *StoreACL) Execute(args ...any) error {
 		return fmt.Errorf("could not load configuration (%v)", err)
 	}
 
-	u, devices := getDevices(conf, cmd.debug)
+	u, devices := getDevices(conf, cmd.timeout, cmd.debug)
+
+	// ... get ACL table column mapping
+	columns, err := cmd.getColumns()
+	if err != nil {
+		return err
+	}
+
+	// ... get PIN key
+	key, err := cmd.loadPINKey()
+	if err != nil {
+		return err
+	}
 
 	// ... retrieve ACL from controllers
 	if acl, err := cmd.getACL(u, devices); err != nil {
 		return err
 	} else if acl == nil {
 		return fmt.Errorf("invalid ACL (%v)", acl)
-	} else if err := putACL(cmd.dsn, cmd.tables.ACL, *acl, cmd.withPIN); err != nil {
+	} else if err := encryptPINs(acl, key); err != nil {
+		return err
+	} else if err := putACL(cmd.dsn, cmd.tls, cmd.tables.ACL, *acl, columns, cmd.withPIN); err != nil {
 		return err
 	} else {
-		infof("store-acl", "Updated DB ACL table")
+		cmd.infof("store-acl", "Updated DB ACL table")
 
 		if cmd.tables.Log != "" {
 			recordset := []db.LogRecord{
@@ -107,10 +127,13 @@ func (cmd *StoreACL) Execute(args ...any) error {
 					Timestamp: time.Now(),
 					Operation: "store-acl",
 					Detail:    fmt.Sprintf("records:%v", len(acl.Records)),
+					Counters: map[string]int{
+						"records": len(acl.Records),
+					},
 				},
 			}
 
-			if err := stashToLog(cmd.dsn, cmd.tables.Log, recordset); err != nil {
+			if err := cmd.stashToLog(elapsed(recordset, cmd.started)); err != nil {
 				return err
 			}
 		}
@@ -126,7 +149,7 @@ func (cmd *StoreACL) getACL(u uhppote.IUHPPOTE, devices []uhppote.Device) (*lib.
 	}
 
 	for k, l := range acl {
-		infof("store-acl", "%v  Retrieved %v records", k, len(l))
+		cmd.infof("store-acl", "%v  Retrieved %v records", k, len(l))
 	}
 
 	if cmd.withPIN {
@@ -0,0 +1,232 @@
+package commands
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsx is the minimal subset of the Office Open XML spreadsheet format needed to read the cell values
+// of the first worksheet in an Excel workbook.
+type xlsx struct {
+	files map[string]*zip.File
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	s := t.T
+	for _, r := range t.Runs {
+		s += r.T
+	}
+
+	return s
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX returns the rows of the first worksheet in an Excel workbook. Numeric cells are returned as
+// formatted by Excel i.e. dates are returned as Excel serial day numbers.
+func readXLSX(file string) ([][]string, error) {
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	x := xlsx{
+		files: map[string]*zip.File{},
+	}
+
+	for _, f := range r.File {
+		x.files[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	var relationships xlsxRelationships
+	var sst xlsxSharedStrings
+	var sheet xlsxWorksheet
+
+	if err := x.unmarshal("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	} else if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no worksheets")
+	} else if err := x.unmarshal("xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+
+	if _, ok := x.files["xl/sharedStrings.xml"]; ok {
+		if err := x.unmarshal("xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+	}
+
+	target := ""
+	for _, rel := range relationships.Relationships {
+		if rel.ID == workbook.Sheets[0].RID {
+			if strings.HasPrefix(rel.Target, "/") {
+				target = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				target = path.Join("xl", rel.Target)
+			}
+		}
+	}
+
+	if target == "" {
+		return nil, fmt.Errorf("missing worksheet '%v'", workbook.Sheets[0].Name)
+	} else if err := x.unmarshal(target, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{}
+	for _, row := range sheet.Rows {
+		record := []string{}
+
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				if c, err := xlsxColumn(cell.Ref); err != nil {
+					return nil, err
+				} else {
+					col = c
+				}
+			}
+
+			for len(record) <= col {
+				record = append(record, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				if ix, err := strconv.Atoi(cell.Value); err != nil || ix < 0 || ix >= len(sst.Items) {
+					return nil, fmt.Errorf("invalid shared string index (%v) in cell %v", cell.Value, cell.Ref)
+				} else {
+					record[col] = sst.Items[ix].String()
+				}
+
+			case "inlineStr":
+				record[col] = cell.Inline.String()
+
+			case "b":
+				if cell.Value == "1" {
+					record[col] = "Y"
+				} else {
+					record[col] = "N"
+				}
+
+			case "", "n":
+				record[col] = xlsxNumber(cell.Value)
+
+			case "d":
+				record[col] = xlsxDate(cell.Value)
+
+			default:
+				record[col] = cell.Value
+			}
+		}
+
+		rows = append(rows, record)
+	}
+
+	return rows, nil
+}
+
+func (x xlsx) unmarshal(name string, v any) error {
+	f, ok := x.files[name]
+	if !ok {
+		return fmt.Errorf("invalid XLSX file (missing %v)", name)
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	if bytes, err := io.ReadAll(r); err != nil {
+		return err
+	} else if err := xml.Unmarshal(bytes, v); err != nil {
+		return fmt.Errorf("invalid XLSX file %v (%v)", name, err)
+	}
+
+	return nil
+}
+
+// xlsxColumn returns the zero-based column index for a cell reference e.g. 'C7'.
+func xlsxColumn(ref string) (int, error) {
+	col := 0
+	for _, ch := range ref {
+		if ch >= 'A' && ch <= 'Z' {
+			col = 26*col + int(ch-'A') + 1
+		} else if ch >= '0' && ch <= '9' {
+			break
+		} else {
+			return 0, fmt.Errorf("invalid cell reference (%v)", ref)
+		}
+	}
+
+	if col == 0 {
+		return 0, fmt.Errorf("invalid cell reference (%v)", ref)
+	}
+
+	return col - 1, nil
+}
+
+// xlsxNumber formats integral numeric cell values (e.g. '10058400.0' or '1.00584E7') as integers.
+func xlsxNumber(v string) string {
+	if f, err := strconv.ParseFloat(v, 64); err == nil && f == float64(int64(f)) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+
+	return v
+}
+
+// xlsxDate formats ISO 8601 date cell values (e.g. '2025-01-01T00:00:00') as dates.
+func xlsxDate(v string) string {
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999Z07:00", "2006-01-02T15:04:05.999999999", "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+
+	return v
+}
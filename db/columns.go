@@ -0,0 +1,88 @@
+package db
+
+import (
+	"strings"
+)
+
+// Columns maps the columns of an ACL table to the card number, PIN, start and end dates, optional
+// card status, optional controller scope and the door permissions. If no door columns are listed,
+// every column that is not otherwise mapped or ignored is treated as a door column named for the door.
+type Columns struct {
+	CardNumber  string
+	PIN         string
+	StartDate   string
+	EndDate     string
+	Status      string
+	Controllers string
+	Doors       map[string]string
+	Ignore      []string
+}
+
+var DefaultColumns = Columns{
+	CardNumber:  "CardNumber",
+	PIN:         "PIN",
+	StartDate:   "StartDate",
+	EndDate:     "EndDate",
+	Status:      "Status",
+	Controllers: "Controllers",
+	Doors:       map[string]string{},
+	Ignore:      []string{"Name"},
+}
+
+// Door returns the door name for a door column and false if the column is not a door column.
+func (c Columns) Door(column string) (string, bool) {
+	k := normalise(column)
+
+	if k == normalise(c.CardNumber) || k == normalise(c.PIN) || k == normalise(c.StartDate) || k == normalise(c.EndDate) || k == normalise(c.Status) || k == normalise(c.Controllers) {
+		return "", false
+	}
+
+	if c.ignored(column) {
+		return "", false
+	}
+
+	if len(c.Doors) == 0 {
+		return column, true
+	}
+
+	for col, door := range c.Doors {
+		if normalise(col) == k {
+			return door, true
+		}
+	}
+
+	return "", false
+}
+
+// Column returns the ACL table column for a door and false if the door is not mapped to a column.
+func (c Columns) Column(door string) (string, bool) {
+	if len(c.Doors) == 0 {
+		if column := strings.ReplaceAll(door, " ", ""); !c.ignored(column) {
+			return column, true
+		}
+
+		return "", false
+	}
+
+	for col, d := range c.Doors {
+		if normalise(d) == normalise(door) {
+			return col, true
+		}
+	}
+
+	return "", false
+}
+
+func (c Columns) ignored(column string) bool {
+	for _, v := range c.Ignore {
+		if normalise(v) == normalise(column) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalise(v string) string {
+	return strings.ToLower(strings.ReplaceAll(v, " ", ""))
+}
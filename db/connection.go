@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"time"
+)
+
+// ConnectionError is returned by the DB backends if the database could not be opened or reached (e.g. the
+// DB server is down or the network is unavailable), as distinct from errors reported by the database.
+type ConnectionError struct {
+	Err error
+}
+
+func (e ConnectionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// Ping verifies the connection to the database, returning a ConnectionError if the DB server could not
+// be reached. Other errors (e.g. invalid credentials) are returned unchanged.
+func Ping(dbc *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	var nerr net.Error
+
+	if err := dbc.PingContext(ctx); err == nil {
+		return nil
+	} else if errors.As(err, &nerr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) {
+		return ConnectionError{Err: err}
+	} else {
+		return err
+	}
+}
@@ -8,12 +8,20 @@ import (
 )
 
 type DB interface {
-	GetACL(table string, withPIN bool) (*lib.Table, error)
-	PutACL(table string, acl lib.Table, withPIN bool) (int, error)
+	GetACL(table string, columns Columns, withPIN bool) (*lib.Table, error)
+	PutACL(table string, acl lib.Table, columns Columns, withPIN bool) (int, error)
 	GetEvents(table string, controller uint32) ([]uint32, error)
+	GetControllers(table string) ([]uint32, error)
 	PutEvents(table string, events []core.Event) (int, error)
 	AuditTrail(table string, trail []AuditRecord) (int, error)
 	Log(table string, rs []LogRecord) (int, error)
+	GetGroups(table string, columns Columns) ([]Group, error)
+	GetGroupMembers(table string) ([]GroupMember, error)
+	GetTimeProfiles(table string) ([]core.TimeProfile, error)
+	GetHolidays(table string) ([]Holiday, error)
+	Query(table string, filter Filter) ([]Record, error)
+	CheckPermissions(table string, column string, operations []Operation) ([]Permission, error)
+	ExpireCards(table string, columns Columns, cards []uint32, status string) (int, error)
 }
 
 type AuditRecord struct {
@@ -23,11 +31,74 @@ type AuditRecord struct {
 	CardNumber uint32
 	Status     string
 	Card       string
+	RunID      string
 }
 
+// LogRecord is an operations log entry. RunID and the counters are stored in the optional RunID and
+// Counters (JSON) columns if the operations log table has them. The counters listed in LogCounters and
+// the duration are also stored in the matching optional numeric columns (e.g. Added, duration_ms).
 type LogRecord struct {
 	Timestamp  time.Time
 	Operation  string
 	Controller uint32
 	Detail     string
+	RunID      string
+	Counters   map[string]int
+	Duration   time.Duration
+}
+
+// LogCounters is the list of counters stored in the optional numeric operations log columns.
+var LogCounters = []string{"unchanged", "updated", "added", "deleted", "failed", "errors", "records", "incorrect", "missing", "extra", "fixed"}
+
+// Holiday is a holidays table entry. A zero Controller applies the holiday to all controllers.
+type Holiday struct {
+	Date       core.Date
+	Name       string
+	Controller uint32
+}
+
+type Group struct {
+	Name  string
+	Doors map[string]string
+}
+
+type GroupMember struct {
+	CardNumber uint32
+	Group      string
+	StartDate  core.Date
+	EndDate    core.Date
+}
+
+// Filter restricts the records returned by Query to the records matching the Where column values, with
+// column values greater than the After column values (e.g. {"EventIndex": 1234}) and within the timestamp
+// range. Records are returned most recent first, up to the limit (if not 0).
+type Filter struct {
+	Where map[string]any
+	After map[string]any
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+type Record map[string]any
+
+// Operation is a table operation used by a command.
+type Operation string
+
+const (
+	Select Operation = "SELECT"
+	Insert Operation = "INSERT"
+	Upsert Operation = "UPSERT"
+	Update Operation = "UPDATE"
+	Delete Operation = "DELETE"
+)
+
+// Permission is the result of testing the grant required for a table operation. A nil Err
+// indicates that the operation is permitted. Denied is set if Err is a database permission error,
+// as opposed to e.g. a missing table or column or a timeout.
+type Permission struct {
+	Operation Operation
+	Grant     string
+	Denied    bool
+	Err       error
 }
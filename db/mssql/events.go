@@ -7,15 +7,17 @@ import (
 	"time"
 
 	core "github.com/uhppoted/uhppote-core/types"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
 )
 
-func GetEvents(dsn string, table string, controller uint32) ([]uint32, error) {
+func GetEvents(dsn string, tls db.TLS, table string, controller uint32) ([]uint32, error) {
 	query := fmt.Sprintf(`SELECT EventIndex FROM %v WHERE Controller=?;`, table)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
 	defer cancel()
 
-	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+	if dbc, err := open(dsn, tls, MaxLifetime, MaxIdle, MaxOpen); err != nil {
 		return nil, err
 	} else if dbc == nil {
 		return nil, fmt.Errorf("invalid SQL Server DB (%v)", dbc)
@@ -43,12 +45,47 @@ func GetEvents(dsn string, table string, controller uint32) ([]uint32, error) {
 	}
 }
 
-func PutEvents(dsn string, table string, events []core.Event) (int, error) {
+// GetControllers returns the list of controllers with events in the events table.
+func GetControllers(dsn string, tls db.TLS, table string) ([]uint32, error) {
+	query := fmt.Sprintf(`SELECT DISTINCT Controller FROM %v;`, table)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	if dbc, err := open(dsn, tls, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+		return nil, err
+	} else if dbc == nil {
+		return nil, fmt.Errorf("invalid SQL Server DB (%v)", dbc)
+	} else if prepared, err := dbc.Prepare(query); err != nil {
+		return nil, err
+	} else if rs, err := prepared.QueryContext(ctx); err != nil {
+		return nil, err
+	} else if rs == nil {
+		return nil, fmt.Errorf("invalid resultset (%v)", rs)
+	} else {
+		defer rs.Close()
+
+		controllers := []uint32{}
+
+		for rs.Next() {
+			var controller uint32
+			if err := rs.Scan(&controller); err != nil {
+				return nil, err
+			} else {
+				controllers = append(controllers, controller)
+			}
+		}
+
+		return controllers, nil
+	}
+}
+
+func PutEvents(dsn string, tls db.TLS, table string, events []core.Event) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
 	defer cancel()
 
-	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+	if dbc, err := open(dsn, tls, MaxLifetime, MaxIdle, MaxOpen); err != nil {
 		return 0, err
 	} else if dbc == nil {
 		return 0, fmt.Errorf("invalid SQL Server DB (%v)", dbc)
@@ -3,18 +3,21 @@ package mssql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/uhppoted/uhppoted-app-db/db"
 )
 
-func Log(dsn string, table string, recordset []db.LogRecord) (int, error) {
+func Log(dsn string, tls db.TLS, table string, recordset []db.LogRecord) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
 	defer cancel()
 
-	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+	if dbc, err := open(dsn, tls, MaxLifetime, MaxIdle, MaxOpen); err != nil {
 		return 0, err
 	} else if dbc == nil {
 		return 0, fmt.Errorf("invalid SQL Server DB (%v)", dbc)
@@ -30,42 +33,98 @@ func Log(dsn string, table string, recordset []db.LogRecord) (int, error) {
 }
 
 func appendToLog(dbc *sql.DB, tx *sql.Tx, table string, recordset []db.LogRecord) (int, error) {
-	count := 0
-	insert := []string{
-		fmt.Sprintf("INSERT INTO %v (Operation, Detail) VALUES (?,?);", table),
-		fmt.Sprintf("INSERT INTO %v (Operation, Controller, Detail) VALUES (?,?,?);", table),
-	}
+	runID := false
+	counters := false
+	numeric := map[string]string{}
+	duration := ""
+	timestamp := false
 
-	prepared := []*sql.Stmt{}
+	// ... get columns
+	if columns, err := getColumns(dbc, tx, table); err != nil {
+		return 0, err
+	} else {
+		for _, col := range columns {
+			if normalise(col) == "runid" {
+				runID = true
+			}
 
-	for _, sql := range insert {
-		if stmt, err := dbc.Prepare(sql); err != nil {
-			return 0, err
-		} else {
-			prepared = append(prepared, stmt)
+			if normalise(col) == "counters" {
+				counters = true
+			}
+
+			if slices.Contains(db.LogCounters, normalise(col)) {
+				numeric[normalise(col)] = col
+			}
+
+			if normalise(col) == "duration_ms" {
+				duration = col
+			}
+
+			if normalise(col) == "timestamp" {
+				timestamp = true
+			}
 		}
 	}
 
+	// ... append records
+	count := 0
+
 	for _, record := range recordset {
-		if record.Controller == 0 {
-			row := []any{record.Operation, record.Detail}
+		columns := []string{"Operation"}
+		row := []any{record.Operation}
+
+		if record.Controller != 0 {
+			columns = append(columns, "Controller")
+			row = append(row, record.Controller)
+		}
 
-			if _, err := tx.Stmt(prepared[0]).Exec(row...); err != nil {
+		columns = append(columns, "Detail")
+		row = append(row, record.Detail)
+
+		if runID {
+			columns = append(columns, "RunID")
+			row = append(row, record.RunID)
+		}
+
+		if counters && len(record.Counters) > 0 {
+			if bytes, err := json.Marshal(record.Counters); err != nil {
 				return 0, err
 			} else {
-				count++
-				debugf("log: stored operations record")
+				columns = append(columns, "Counters")
+				row = append(row, string(bytes))
 			}
-		} else {
-			row := []any{record.Operation, record.Controller, record.Detail}
+		}
 
-			if _, err := tx.Stmt(prepared[1]).Exec(row...); err != nil {
-				return 0, err
-			} else {
-				count++
-				debugf("log: stored operations record for %v", record.Controller)
+		for _, counter := range db.LogCounters {
+			if column, ok := numeric[counter]; ok {
+				columns = append(columns, column)
+
+				if v, ok := record.Counters[counter]; ok {
+					row = append(row, v)
+				} else {
+					row = append(row, nil)
+				}
 			}
 		}
+
+		if duration != "" {
+			columns = append(columns, duration)
+			row = append(row, record.Duration.Milliseconds())
+		}
+
+		if timestamp && !record.Timestamp.IsZero() {
+			columns = append(columns, "Timestamp")
+			row = append(row, record.Timestamp.UTC().Format("2006-01-02 15:04:05"))
+		}
+
+		sql := fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v);", table, strings.Join(columns, ", "), placeholders(len(columns)))
+
+		if _, err := tx.Exec(sql, row...); err != nil {
+			return 0, err
+		} else {
+			count++
+			debugf("log: stored operations record for %v", record.Controller)
+		}
 	}
 
 	return count, nil
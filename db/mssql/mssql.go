@@ -3,6 +3,7 @@ package mssql
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -24,39 +25,80 @@ type record map[string]any
 
 type dbi struct {
 	dsn string
+	tls db.TLS
 }
 
-func NewDB(dsn string) db.DB {
+// NewDB returns a DB for the DSN. The TLS settings (if any) are applied to every connection opened
+// for the DSN.
+func NewDB(dsn string, tls db.TLS) db.DB {
 	return dbi{
 		dsn: dsn,
+		tls: tls,
 	}
 }
 
-func (d dbi) GetACL(table string, withPIN bool) (*lib.Table, error) {
-	return GetACL(d.dsn, table, withPIN)
+func (d dbi) GetACL(table string, columns db.Columns, withPIN bool) (*lib.Table, error) {
+	return GetACL(d.dsn, d.tls, table, columns, withPIN)
 }
 
-func (d dbi) PutACL(table string, acl lib.Table, withPIN bool) (int, error) {
-	return PutACL(d.dsn, table, acl, withPIN)
+func (d dbi) PutACL(table string, acl lib.Table, columns db.Columns, withPIN bool) (int, error) {
+	return PutACL(d.dsn, d.tls, table, acl, columns, withPIN)
 }
 
 func (d dbi) GetEvents(table string, controller uint32) ([]uint32, error) {
-	return GetEvents(d.dsn, table, controller)
+	return GetEvents(d.dsn, d.tls, table, controller)
+}
+
+func (d dbi) GetControllers(table string) ([]uint32, error) {
+	return GetControllers(d.dsn, d.tls, table)
 }
 
 func (d dbi) PutEvents(table string, events []core.Event) (int, error) {
-	return PutEvents(d.dsn, table, events)
+	return PutEvents(d.dsn, d.tls, table, events)
 }
 
 func (d dbi) AuditTrail(table string, trail []db.AuditRecord) (int, error) {
-	return AuditTrail(d.dsn, table, trail)
+	return AuditTrail(d.dsn, d.tls, table, trail)
 }
 
 func (d dbi) Log(table string, rs []db.LogRecord) (int, error) {
-	return Log(d.dsn, table, rs)
+	return Log(d.dsn, d.tls, table, rs)
+}
+
+func (d dbi) GetGroups(table string, columns db.Columns) ([]db.Group, error) {
+	return GetGroups(d.dsn, d.tls, table, columns)
+}
+
+func (d dbi) GetGroupMembers(table string) ([]db.GroupMember, error) {
+	return GetGroupMembers(d.dsn, d.tls, table)
+}
+
+func (d dbi) GetTimeProfiles(table string) ([]core.TimeProfile, error) {
+	return GetTimeProfiles(d.dsn, d.tls, table)
+}
+
+func (d dbi) GetHolidays(table string) ([]db.Holiday, error) {
+	return GetHolidays(d.dsn, d.tls, table)
 }
 
-func open(dsn string, maxLifetime time.Duration, maxOpen int, maxIdle int) (*sql.DB, error) {
+func (d dbi) Query(table string, filter db.Filter) ([]db.Record, error) {
+	return Query(d.dsn, d.tls, table, filter)
+}
+
+func (d dbi) CheckPermissions(table string, column string, operations []db.Operation) ([]db.Permission, error) {
+	return CheckPermissions(d.dsn, d.tls, table, column, operations)
+}
+
+func (d dbi) ExpireCards(table string, columns db.Columns, cards []uint32, status string) (int, error) {
+	return ExpireCards(d.dsn, d.tls, table, columns, cards, status)
+}
+
+func open(dsn string, tls db.TLS, maxLifetime time.Duration, maxOpen int, maxIdle int) (*sql.DB, error) {
+	dsn, err := withTLS(dsn, tls)
+	if err != nil {
+		return nil, err
+	}
+
 	dbc, err := sql.Open("mssql", dsn)
 	if err != nil {
 		return nil, err
@@ -69,6 +111,59 @@ func open(dsn string, maxLifetime time.Duration, maxOpen int, maxIdle int) (*sql
 	return dbc, nil
 }
 
+// withTLS returns the DSN with the TLS settings (if any) mapped to the SQL Server DSN encrypt,
+// TrustServerCertificate, certificate and hostNameInCertificate parameters. The SQL Server driver
+// does not support client certificates or verifying the CA without the server name.
+func withTLS(dsn string, settings db.TLS) (string, error) {
+	if settings.Mode == "" {
+		return dsn, nil
+	} else if err := settings.Validate(); err != nil {
+		return "", err
+	} else if settings.Certificate != "" {
+		return "", fmt.Errorf("TLS client certificates are not supported for SQL Server")
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	set := func(key, value string) {
+		for k := range query {
+			if strings.EqualFold(k, key) {
+				query.Del(k)
+			}
+		}
+
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	switch settings.Mode {
+	case db.TLSDisable:
+		set("encrypt", "disable")
+
+	case db.TLSRequire:
+		set("encrypt", "true")
+		set("TrustServerCertificate", "true")
+
+	case db.TLSVerifyCA:
+		return "", fmt.Errorf("TLS mode %v is not supported for SQL Server (use %v)", db.TLSVerifyCA, db.TLSVerifyFull)
+
+	case db.TLSVerifyFull:
+		set("encrypt", "true")
+		set("TrustServerCertificate", "false")
+		set("certificate", settings.CA)
+		set("hostNameInCertificate", settings.ServerName)
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
 func row2record(rows *sql.Rows, columns []string, types []*sql.ColumnType) (record, error) {
 	values := make([]any, len(types))
 	pointers := make([]any, len(values))
@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/uhppoted/uhppoted-app-db/db"
 	lib "github.com/uhppoted/uhppoted-lib/acl"
 )
 
-func makeTable(columns []string, recordset []record, withPIN bool) (*lib.Table, error) {
+func makeTable(columns []string, recordset []record, mapping db.Columns, withPIN bool) (*lib.Table, error) {
 	if len(recordset) == 0 {
 		return nil, fmt.Errorf("empty ACL table")
 	}
@@ -25,49 +27,82 @@ func makeTable(columns []string, recordset []record, withPIN bool) (*lib.Table,
 		index[k] = v
 	}
 
+	keys := struct {
+		cardnumber string
+		pin        string
+		startdate  string
+		enddate    string
+		status     string
+		scope      string
+	}{
+		cardnumber: normalise(mapping.CardNumber),
+		pin:        normalise(mapping.PIN),
+		startdate:  normalise(mapping.StartDate),
+		enddate:    normalise(mapping.EndDate),
+		status:     normalise(mapping.Status),
+		scope:      normalise(mapping.Controllers),
+	}
+
 	// ... build header
 	header := []string{}
 
-	if _, ok := index["cardnumber"]; !ok {
-		return nil, fmt.Errorf("missing 'card number' column")
+	if _, ok := index[keys.cardnumber]; !ok {
+		return nil, fmt.Errorf("missing 'card number' column (%v)", mapping.CardNumber)
 	} else {
 		header = append(header, "Card Number")
 	}
 
 	if withPIN {
-		if _, ok := index["pin"]; !ok {
-			return nil, fmt.Errorf("missing 'PIN' column")
+		if _, ok := index[keys.pin]; !ok {
+			return nil, fmt.Errorf("missing 'PIN' column (%v)", mapping.PIN)
 		} else {
 			header = append(header, "PIN")
 		}
 	}
 
-	if _, ok := index["startdate"]; !ok {
-		return nil, fmt.Errorf("missing 'from' column")
+	if _, ok := index[keys.startdate]; !ok {
+		return nil, fmt.Errorf("missing 'from' column (%v)", mapping.StartDate)
 	} else {
 		header = append(header, "From")
 	}
 
-	if _, ok := index["enddate"]; !ok {
-		return nil, fmt.Errorf("missing 'to' column")
+	if _, ok := index[keys.enddate]; !ok {
+		return nil, fmt.Errorf("missing 'to' column (%v)", mapping.EndDate)
 	} else {
 		header = append(header, "To")
 	}
 
+	// ... door columns
+	doors := []string{}
+	doormap := map[string]string{}
+
 	for _, v := range columns {
-		k := normalise(v)
-		if k != "cardnumber" && k != "startdate" && k != "enddate" && k != "name" && k != "pin" {
-			header = append(header, clean(v))
+		if door, ok := mapping.Door(v); ok {
+			header = append(header, clean(door))
+			doors = append(doors, clean(door))
+			doormap[normalise(door)] = v
 		}
 	}
 
+	// ... optional card status
+	_, status := index[keys.status]
+	if status {
+		header = append(header, "Status")
+	}
+
+	// ... optional controller scope
+	_, scope := index[keys.scope]
+	if scope {
+		header = append(header, "Controllers")
+	}
+
 	// ... records
 	rows := [][]string{}
 
 	for _, record := range recordset {
 		row := []string{}
 
-		if cardnumber, ok := record[index["cardnumber"]].(int64); !ok {
+		if cardnumber, ok := record[index[keys.cardnumber]].(int64); !ok {
 			continue
 		} else if cardnumber < 0 || cardnumber > math.MaxUint32 {
 			warnf("mssql", "invalid card number (%v)", cardnumber)
@@ -77,38 +112,28 @@ func makeTable(columns []string, recordset []record, withPIN bool) (*lib.Table,
 		}
 
 		if withPIN {
-			if pin, ok := record[index["pin"]].(int64); !ok {
-				continue
-			} else if pin < 0 || pin > math.MaxUint16 {
-				warnf("mssql", "invalid PIN (%v)", pin)
+			if pin, err := db.PIN(record[index[keys.pin]]); err != nil {
+				warnf("%v for card %v", err, row[0])
 				continue
 			} else {
-				row = append(row, fmt.Sprintf("%v", uint32(pin)))
+				row = append(row, pin)
 			}
 		}
 
-		if from, ok := record[index["startdate"]].(time.Time); !ok {
+		if from, ok := record[index[keys.startdate]].(time.Time); !ok {
 			continue
 		} else {
 			row = append(row, from.Format("2006-01-02"))
 		}
 
-		if to, ok := record[index["enddate"]].(time.Time); !ok {
+		if to, ok := record[index[keys.enddate]].(time.Time); !ok {
 			continue
 		} else {
 			row = append(row, to.Format("2006-01-02"))
 		}
 
-		var doors []string
-
-		if withPIN {
-			doors = header[4:]
-		} else {
-			doors = header[3:]
-		}
-
 		for _, h := range doors {
-			if k, ok := index[normalise(h)]; !ok {
+			if k, ok := doormap[normalise(h)]; !ok {
 				row = append(row, "")
 			} else if s, ok := record[k].(string); ok {
 				if s == "N" || s == "n" {
@@ -131,9 +156,19 @@ func makeTable(columns []string, recordset []record, withPIN bool) (*lib.Table,
 				} else {
 					row = append(row, "")
 				}
+			} else {
+				row = append(row, "")
 			}
 		}
 
+		if status {
+			row = append(row, cardStatus(record[index[keys.status]]))
+		}
+
+		if scope {
+			row = append(row, cardScope(record[index[keys.scope]]))
+		}
+
 		rows = append(rows, row)
 	}
 
@@ -142,3 +177,21 @@ func makeTable(columns []string, recordset []record, withPIN bool) (*lib.Table,
 		Records: rows,
 	}, nil
 }
+
+func cardStatus(v any) string {
+	if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+		return strings.ToLower(strings.TrimSpace(s))
+	}
+
+	return "active"
+}
+
+func cardScope(v any) string {
+	if s, ok := v.(string); ok {
+		return strings.TrimSpace(s)
+	} else if controller, ok := v.(int64); ok && controller > 0 {
+		return fmt.Sprintf("%v", controller)
+	}
+
+	return ""
+}
@@ -6,20 +6,23 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/uhppoted/uhppoted-app-db/db"
 	lib "github.com/uhppoted/uhppoted-lib/acl"
 )
 
-func GetACL(dsn string, table string, withPIN bool) (*lib.Table, error) {
-	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+func GetACL(dsn string, tls db.TLS, table string, mapping db.Columns, withPIN bool) (*lib.Table, error) {
+	if dbc, err := open(dsn, tls, MaxLifetime, MaxIdle, MaxOpen); err != nil {
 		return nil, err
 	} else if dbc == nil {
 		return nil, fmt.Errorf("invalid %v DB (%v)", "MySQL", dbc)
+	} else if err := db.Ping(dbc); err != nil {
+		return nil, err
 	} else {
-		return get(dbc, table, withPIN)
+		return get(dbc, table, mapping, withPIN)
 	}
 }
 
-func get(dbc *sql.DB, table string, withPIN bool) (*lib.Table, error) {
+func get(dbc *sql.DB, table string, mapping db.Columns, withPIN bool) (*lib.Table, error) {
 	sql := fmt.Sprintf(`SELECT * FROM %v;`, table)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
@@ -51,7 +54,7 @@ func get(dbc *sql.DB, table string, withPIN bool) (*lib.Table, error) {
 				}
 			}
 
-			return makeTable(columns, recordset, withPIN)
+			return makeTable(columns, recordset, mapping, withPIN)
 		}
 	}
 }
@@ -2,12 +2,14 @@ package mysql
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"net"
 	"regexp"
 	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 
 	core "github.com/uhppoted/uhppote-core/types"
 	"github.com/uhppoted/uhppoted-app-db/db"
@@ -24,44 +26,82 @@ type record map[string]any
 
 type dbi struct {
 	dsn string
+	tls db.TLS
 }
 
-func NewDB(dsn string) db.DB {
+// NewDB returns a DB for the DSN. The TLS settings (if any) are applied to every connection opened
+// for the DSN.
+func NewDB(dsn string, tls db.TLS) db.DB {
 	return dbi{
 		dsn: dsn,
+		tls: tls,
 	}
 }
 
-func (d dbi) GetACL(table string, withPIN bool) (*lib.Table, error) {
-	return GetACL(d.dsn, table, withPIN)
+func (d dbi) GetACL(table string, columns db.Columns, withPIN bool) (*lib.Table, error) {
+	return GetACL(d.dsn, d.tls, table, columns, withPIN)
 }
 
-func (d dbi) PutACL(table string, acl lib.Table, withPIN bool) (int, error) {
-	return PutACL(d.dsn, table, acl, withPIN)
+func (d dbi) PutACL(table string, acl lib.Table, columns db.Columns, withPIN bool) (int, error) {
+	return PutACL(d.dsn, d.tls, table, acl, columns, withPIN)
 }
 
 func (d dbi) GetEvents(table string, controller uint32) ([]uint32, error) {
-	return GetEvents(d.dsn, table, controller)
+	return GetEvents(d.dsn, d.tls, table, controller)
+}
+
+func (d dbi) GetControllers(table string) ([]uint32, error) {
+	return GetControllers(d.dsn, d.tls, table)
 }
 
 func (d dbi) PutEvents(table string, events []core.Event) (int, error) {
-	return PutEvents(d.dsn, table, events)
+	return PutEvents(d.dsn, d.tls, table, events)
 }
 
 func (d dbi) AuditTrail(table string, trail []db.AuditRecord) (int, error) {
-	return AuditTrail(d.dsn, table, trail)
+	return AuditTrail(d.dsn, d.tls, table, trail)
 }
 
 func (d dbi) Log(table string, rs []db.LogRecord) (int, error) {
-	return Log(d.dsn, table, rs)
+	return Log(d.dsn, d.tls, table, rs)
+}
+
+func (d dbi) GetGroups(table string, columns db.Columns) ([]db.Group, error) {
+	return GetGroups(d.dsn, d.tls, table, columns)
+}
+
+func (d dbi) GetGroupMembers(table string) ([]db.GroupMember, error) {
+	return GetGroupMembers(d.dsn, d.tls, table)
+}
+
+func (d dbi) GetTimeProfiles(table string) ([]core.TimeProfile, error) {
+	return GetTimeProfiles(d.dsn, d.tls, table)
+}
+
+func (d dbi) GetHolidays(table string) ([]db.Holiday, error) {
+	return GetHolidays(d.dsn, d.tls, table)
+}
+
+func (d dbi) Query(table string, filter db.Filter) ([]db.Record, error) {
+	return Query(d.dsn, d.tls, table, filter)
+}
+
+func (d dbi) CheckPermissions(table string, column string, operations []db.Operation) ([]db.Permission, error) {
+	return CheckPermissions(d.dsn, d.tls, table, column, operations)
 }
 
-func open(dsn string, maxLifetime time.Duration, maxOpen int, maxIdle int) (*sql.DB, error) {
-	dbc, err := sql.Open("mysql", dsn)
+func (d dbi) ExpireCards(table string, columns db.Columns, cards []uint32, status string) (int, error) {
+	return ExpireCards(d.dsn, d.tls, table, columns, cards, status)
+}
+
+func open(dsn string, tls db.TLS, maxLifetime time.Duration, maxOpen int, maxIdle int) (*sql.DB, error) {
+	connector, err := newConnector(dsn, tls)
 	if err != nil {
 		return nil, err
 	}
 
+	dbc := sql.OpenDB(connector)
+
 	dbc.SetConnMaxLifetime(maxLifetime)
 	dbc.SetMaxOpenConns(maxOpen)
 	dbc.SetMaxIdleConns(maxIdle)
@@ -69,6 +109,32 @@ func open(dsn string, maxLifetime time.Duration, maxOpen int, maxIdle int) (*sql
 	return dbc, nil
 }
 
+// newConnector returns a MySQL connector for the DSN with the TLS settings (if any) mapped to the driver
+// TLS configuration.
+func newConnector(dsn string, settings db.TLS) (driver.Connector, error) {
+	if cfg, err := mysql.ParseDSN(dsn); err != nil {
+		return nil, err
+	} else if settings.Mode == "" {
+		return mysql.NewConnector(cfg)
+	} else if config, err := settings.Config(hostname(cfg.Addr)); err != nil {
+		return nil, err
+	} else {
+		cfg.TLS = config
+		cfg.TLSConfig = ""
+		cfg.AllowFallbackToPlaintext = false
+
+		return mysql.NewConnector(cfg)
+	}
+}
+
+func hostname(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+
+	return address
+}
+
 func row2record(rows *sql.Rows, columns []string, types []*sql.ColumnType) (record, error) {
 	values := make([]any, len(types))
 	pointers := make([]any, len(values))
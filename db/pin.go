@@ -0,0 +1,57 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EncryptedPINPrefix identifies a keypad PIN that has been encrypted with a PIN key. Encrypted PINs are
+// stored as text in the ACL table and passed through unchanged by the DB backends.
+const EncryptedPINPrefix = "enc:"
+
+// MaxPIN is the largest keypad PIN supported by the controllers.
+const MaxPIN = 999999
+
+// IsEncryptedPIN returns true if the PIN is an encrypted PIN.
+func IsEncryptedPIN(pin string) bool {
+	return strings.HasPrefix(pin, EncryptedPINPrefix)
+}
+
+// PIN returns the keypad PIN for an ACL table PIN column value. The PIN column may be either an integer
+// column or a text column (e.g. VARCHAR) with a numeric or encrypted PIN. A NULL or blank PIN is returned
+// as an empty string.
+func PIN(v any) (string, error) {
+	switch pin := v.(type) {
+	case nil:
+		return "", nil
+
+	case int64:
+		if pin < 0 || pin > MaxPIN {
+			return "", fmt.Errorf("invalid PIN (%v)", pin)
+		} else {
+			return fmt.Sprintf("%v", pin), nil
+		}
+
+	case string:
+		return parsePIN(pin)
+
+	case []uint8:
+		return parsePIN(string(pin))
+
+	default:
+		return "", fmt.Errorf("invalid PIN column type (%T)", v)
+	}
+}
+
+func parsePIN(s string) (string, error) {
+	pin := strings.TrimSpace(s)
+
+	if pin == "" || IsEncryptedPIN(pin) {
+		return pin, nil
+	} else if v, err := strconv.ParseUint(pin, 10, 32); err != nil || v > MaxPIN {
+		return "", fmt.Errorf("invalid PIN (%v)", s)
+	} else {
+		return fmt.Sprintf("%v", v), nil
+	}
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestPIN(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+		err      bool
+	}{
+		{nil, "", false},
+		{int64(7531), "7531", false},
+		{int64(999999), "999999", false},
+		{int64(1000000), "", true},
+		{"7531", "7531", false},
+		{"007531", "7531", false},
+		{"1000000", "", true},
+		{"12a4", "", true},
+		{"enc:abcdef", "enc:abcdef", false},
+		{[]uint8("7531"), "7531", false},
+		{float64(7531), "", true},
+	}
+
+	for _, test := range tests {
+		pin, err := PIN(test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("%#v: expected error, got %q", test.value, pin)
+			}
+		} else if err != nil {
+			t.Errorf("%#v: unexpected error (%v)", test.value, err)
+		} else if pin != test.expected {
+			t.Errorf("%#v: incorrect PIN - expected:%q, got:%q", test.value, test.expected, pin)
+		}
+	}
+}
@@ -4,17 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/uhppoted/uhppoted-app-db/db"
 )
 
-func AuditTrail(dsn string, table string, recordset []db.AuditRecord) (int, error) {
+func AuditTrail(dsn string, tls db.TLS, table string, recordset []db.AuditRecord) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
 	defer cancel()
 
-	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+	if dbc, err := open(dsn, tls, MaxLifetime, MaxIdle, MaxOpen); err != nil {
 		return 0, err
 	} else if dbc == nil {
 		return 0, fmt.Errorf("invalid PostgreSQL DB (%v)", dbc)
@@ -32,6 +34,8 @@ func AuditTrail(dsn string, table string, recordset []db.AuditRecord) (int, erro
 func appendToAuditTrail(dbc *sql.DB, tx *sql.Tx, table string, recordset []db.AuditRecord) (int, error) {
 	cardNumber := false
 	card := false
+	runID := false
+	timestamp := false
 
 	// ... get columns
 	if columns, err := getColumns(dbc, tx, table); err != nil {
@@ -45,29 +49,70 @@ func appendToAuditTrail(dbc *sql.DB, tx *sql.Tx, table string, recordset []db.Au
 			if normalise(col) == "cardnumber" {
 				cardNumber = true
 			}
+
+			if normalise(col) == "runid" {
+				runID = true
+			}
+
+			if normalise(col) == "timestamp" {
+				timestamp = true
+			}
 		}
 	}
 
+	// ... use the record timestamps unless a record has none, in which case the column DEFAULT is used
+	if slices.ContainsFunc(recordset, func(r db.AuditRecord) bool { return r.Timestamp.IsZero() }) {
+		timestamp = false
+	}
+
 	// ... append records
 	count := 0
 	insert := func() string {
-		if card && cardNumber {
-			return fmt.Sprintf("INSERT INTO %v (Operation, Controller, CardNumber, Status, Card) VALUES ($1,$2,$3,$4,$5);", table)
-		} else if card {
-			return fmt.Sprintf("INSERT INTO %v (Operation, Controller, Status, Card) VALUES ($1,$2,$3,$4);", table)
-		} else {
-			return fmt.Sprintf("INSERT INTO %v (Operation, Controller, CardNumber, Status) VALUES ($1,$2,$3,$4);", table)
+		columns := []string{"Operation", "Controller"}
+
+		if cardNumber || !card {
+			columns = append(columns, "CardNumber")
+		}
+
+		columns = append(columns, "Status")
+
+		if card {
+			columns = append(columns, "Card")
+		}
+
+		if runID {
+			columns = append(columns, "RunID")
 		}
+
+		if timestamp {
+			columns = append(columns, "Timestamp")
+		}
+
+		return fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v);", table, strings.Join(columns, ", "), placeholders(len(columns)))
 	}
 
 	g := func(r db.AuditRecord) []any {
-		if card && cardNumber {
-			return []any{r.Operation, r.Controller, r.CardNumber, r.Status, r.Card}
-		} else if card {
-			return []any{r.Operation, r.Controller, r.Status, r.Card}
-		} else {
-			return []any{r.Operation, r.Controller, r.CardNumber, r.Status}
+		row := []any{r.Operation, r.Controller}
+
+		if cardNumber || !card {
+			row = append(row, r.CardNumber)
 		}
+
+		row = append(row, r.Status)
+
+		if card {
+			row = append(row, r.Card)
+		}
+
+		if runID {
+			row = append(row, r.RunID)
+		}
+
+		if timestamp {
+			row = append(row, r.Timestamp.Format("2006-01-02 15:04:05"))
+		}
+
+		return row
 	}
 
 	if prepared, err := dbc.Prepare(insert()); err != nil {
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+// ExpireCards sets the status of the cards in the ACL table to the status or, if the status is empty,
+// deletes the cards from the ACL table.
+func ExpireCards(dsn string, tls db.TLS, table string, columns db.Columns, cards []uint32, status string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	var sql string
+	if status != "" {
+		sql = fmt.Sprintf("UPDATE %v SET %v=%v WHERE %v=%v;", table, columns.Status, placeholder(1), columns.CardNumber, placeholder(2))
+	} else {
+		sql = fmt.Sprintf("DELETE FROM %v WHERE %v=%v;", table, columns.CardNumber, placeholder(1))
+	}
+
+	if dbc, err := open(dsn, tls, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+		return 0, err
+	} else if dbc == nil {
+		return 0, fmt.Errorf("invalid PostgreSQL DB (%v)", dbc)
+	} else if tx, err := dbc.BeginTx(ctx, nil); err != nil {
+		return 0, err
+	} else if prepared, err := tx.Prepare(sql); err != nil {
+		tx.Rollback()
+		return 0, err
+	} else {
+		count := 0
+
+		for _, card := range cards {
+			args := []any{card}
+			if status != "" {
+				args = []any{status, card}
+			}
+
+			if result, err := prepared.Exec(args...); err != nil {
+				tx.Rollback()
+				return 0, err
+			} else if N, err := result.RowsAffected(); err != nil {
+				tx.Rollback()
+				return 0, err
+			} else {
+				count += int(N)
+				debugf("ACL: expired card %v (%v rows)", card, N)
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+
+		return count, nil
+	}
+}
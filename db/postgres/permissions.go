@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+type check struct {
+	grant     string
+	statement string
+}
+
+// CheckPermissions tests the grants required for each of the table operations by executing a statement
+// that does not affect any rows, in a transaction that is always rolled back.
+func CheckPermissions(dsn string, tls db.TLS, table string, column string, operations []db.Operation) ([]db.Permission, error) {
+	if dbc, err := open(dsn, tls, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+		return nil, err
+	} else if dbc == nil {
+		return nil, fmt.Errorf("invalid PostgreSQL DB (%v)", dbc)
+	} else {
+		permissions := []db.Permission{}
+
+		for _, op := range operations {
+			if checks, err := checks(table, column, op); err != nil {
+				return nil, err
+			} else {
+				for _, c := range checks {
+					err := try(dbc, c.statement)
+
+					permissions = append(permissions, db.Permission{
+						Operation: op,
+						Grant:     c.grant,
+						Denied:    denied(err),
+						Err:       err,
+					})
+				}
+			}
+		}
+
+		return permissions, nil
+	}
+}
+
+func checks(table string, column string, op db.Operation) ([]check, error) {
+	if column == "" && (op == db.Insert || op == db.Upsert || op == db.Update) {
+		return nil, fmt.Errorf("missing %v column for %v check", table, op)
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %v (%v) SELECT NULL WHERE 1=2;", table, column)
+
+	switch op {
+	case db.Select:
+		return []check{
+			{"SELECT", fmt.Sprintf("SELECT * FROM %v WHERE 1=2;", table)},
+		}, nil
+
+	case db.Insert:
+		return []check{
+			{"INSERT", insert},
+		}, nil
+
+	case db.Upsert:
+		// ... INSERT .. ON CONFLICT DO UPDATE requires INSERT, UPDATE and SELECT on the conflict column
+		return []check{
+			{"INSERT", insert},
+			{"UPDATE", fmt.Sprintf("UPDATE %v SET %v=NULL WHERE 1=2;", table, column)},
+			{"SELECT", fmt.Sprintf("SELECT %v FROM %v WHERE 1=2;", column, table)},
+		}, nil
+
+	case db.Update:
+		return []check{
+			{"UPDATE", fmt.Sprintf("UPDATE %v SET %v=NULL WHERE 1=2;", table, column)},
+		}, nil
+
+	case db.Delete:
+		return []check{
+			{"DELETE", fmt.Sprintf("DELETE FROM %v WHERE 1=2;", table)},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported operation (%v)", op)
+	}
+}
+
+func try(dbc *sql.DB, statement string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	if tx, err := dbc.BeginTx(ctx, nil); err != nil {
+		return err
+	} else {
+		defer tx.Rollback()
+
+		_, err := tx.ExecContext(ctx, statement)
+
+		return err
+	}
+}
+
+// denied returns true if the error is a PostgreSQL insufficient_privilege (42501) error.
+func denied(err error) bool {
+	var e *pgconn.PgError
+
+	if errors.As(err, &e) {
+		return e.Code == "42501"
+	}
+
+	return false
+}
@@ -51,6 +51,47 @@ func GetEvents(dsn string, table string, controller uint32) ([]uint32, error) {
 	}
 }
 
+// GetControllers returns the list of controllers with events in the events table.
+func GetControllers(dsn string, table string) ([]uint32, error) {
+	query := fmt.Sprintf(`SELECT DISTINCT Controller FROM %v;`, table)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	if _, err := os.Stat(dsn); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("sqlite3 database %v does not exist", dsn)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+		return nil, err
+	} else if dbc == nil {
+		return nil, fmt.Errorf("invalid sqlite3 DB (%v)", dbc)
+	} else if prepared, err := dbc.Prepare(query); err != nil {
+		return nil, err
+	} else if rs, err := prepared.QueryContext(ctx); err != nil {
+		return nil, err
+	} else if rs == nil {
+		return nil, fmt.Errorf("invalid resultset (%v)", rs)
+	} else {
+		defer rs.Close()
+
+		controllers := []uint32{}
+
+		for rs.Next() {
+			var controller uint32
+			if err := rs.Scan(&controller); err != nil {
+				return nil, err
+			} else {
+				controllers = append(controllers, controller)
+			}
+		}
+
+		return controllers, nil
+	}
+}
+
 func PutEvents(dsn string, table string, events []core.Event) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
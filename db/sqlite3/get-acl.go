@@ -8,12 +8,13 @@ import (
 	"os"
 	"time"
 
+	"github.com/uhppoted/uhppoted-app-db/db"
 	lib "github.com/uhppoted/uhppoted-lib/acl"
 )
 
-func GetACL(dsn string, table string, withPIN bool) (*lib.Table, error) {
+func GetACL(dsn string, table string, mapping db.Columns, withPIN bool) (*lib.Table, error) {
 	if _, err := os.Stat(dsn); errors.Is(err, os.ErrNotExist) {
-		return nil, fmt.Errorf("sqlite3 database %v does not exist", dsn)
+		return nil, db.ConnectionError{Err: fmt.Errorf("sqlite3 database %v does not exist", dsn)}
 	} else if err != nil {
 		return nil, err
 	}
@@ -23,11 +24,11 @@ func GetACL(dsn string, table string, withPIN bool) (*lib.Table, error) {
 	} else if dbc == nil {
 		return nil, fmt.Errorf("invalid sqlite3 DB (%v)", dbc)
 	} else {
-		return get(dbc, table, withPIN)
+		return get(dbc, table, mapping, withPIN)
 	}
 }
 
-func get(dbc *sql.DB, table string, withPIN bool) (*lib.Table, error) {
+func get(dbc *sql.DB, table string, mapping db.Columns, withPIN bool) (*lib.Table, error) {
 	sql := fmt.Sprintf(`SELECT * FROM %v;`, table)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
@@ -59,7 +60,7 @@ func get(dbc *sql.DB, table string, withPIN bool) (*lib.Table, error) {
 				}
 			}
 
-			return makeTable(columns, recordset, withPIN)
+			return makeTable(columns, recordset, mapping, withPIN)
 		}
 	}
 }
@@ -0,0 +1,41 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestGetACLConnectionError(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "acl.db")
+
+	if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+		t.Fatalf("%v", err)
+	} else if _, err := dbc.Exec(`CREATE TABLE Events (EventIndex INTEGER);`); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		dbc.Close()
+	}
+
+	tests := []struct {
+		name        string
+		dsn         string
+		unavailable bool
+	}{
+		{"missing database", filepath.Join(t.TempDir(), "missing.db"), true},
+		{"missing table", dsn, false},
+	}
+
+	for _, test := range tests {
+		var unavailable db.ConnectionError
+
+		if _, err := GetACL(test.dsn, "ACL", db.DefaultColumns, false); err == nil {
+			t.Errorf("%v: expected error, got nil", test.name)
+		} else if errors.As(err, &unavailable) != test.unavailable {
+			t.Errorf("%v: incorrect connection error - expected:%v, got:%T (%v)", test.name, test.unavailable, err, err)
+		}
+	}
+}
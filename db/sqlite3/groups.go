@@ -0,0 +1,191 @@
+package sqlite3
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	core "github.com/uhppoted/uhppote-core/types"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func GetGroups(dsn string, table string, mapping db.Columns) ([]db.Group, error) {
+	if _, err := os.Stat(dsn); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("sqlite3 database %v does not exist", dsn)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+		return nil, err
+	} else if dbc == nil {
+		return nil, fmt.Errorf("invalid sqlite3 DB (%v)", dbc)
+	} else if columns, recordset, err := query(dbc, table); err != nil {
+		return nil, err
+	} else {
+		groups := []db.Group{}
+
+		for _, record := range recordset {
+			group := db.Group{
+				Doors: map[string]string{},
+			}
+
+			for _, c := range columns {
+				if normalise(c) == "name" {
+					if name, ok := record[c].(string); ok {
+						group.Name = clean(name)
+					}
+				} else if door, ok := mapping.Door(c); ok {
+					group.Doors[clean(door)] = permission(record[c])
+				}
+			}
+
+			if group.Name == "" {
+				warnf("groups: ignoring group with missing name")
+			} else {
+				groups = append(groups, group)
+			}
+		}
+
+		return groups, nil
+	}
+}
+
+func GetGroupMembers(dsn string, table string) ([]db.GroupMember, error) {
+	if _, err := os.Stat(dsn); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("sqlite3 database %v does not exist", dsn)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+		return nil, err
+	} else if dbc == nil {
+		return nil, fmt.Errorf("invalid sqlite3 DB (%v)", dbc)
+	} else if columns, recordset, err := query(dbc, table); err != nil {
+		return nil, err
+	} else {
+		index := map[string]string{}
+		for _, c := range columns {
+			index[normalise(c)] = c
+		}
+
+		if _, ok := index["cardnumber"]; !ok {
+			return nil, fmt.Errorf("missing 'card number' column")
+		}
+
+		if _, ok := index["groupname"]; !ok {
+			return nil, fmt.Errorf("missing 'group name' column")
+		}
+
+		date := func(v any) core.Date {
+			if s, ok := v.(string); ok && s != "" {
+				if d, err := core.ParseDate(s); err != nil {
+					warnf("groups: invalid date (%v)", s)
+				} else {
+					return d
+				}
+			}
+
+			return core.Date{}
+		}
+
+		members := []db.GroupMember{}
+
+		for _, record := range recordset {
+			member := db.GroupMember{}
+
+			if cardnumber, ok := record[index["cardnumber"]].(int64); !ok {
+				continue
+			} else if cardnumber < 0 || cardnumber > math.MaxUint32 {
+				warnf("groups: invalid card number (%v)", cardnumber)
+				continue
+			} else {
+				member.CardNumber = uint32(cardnumber)
+			}
+
+			if group, ok := record[index["groupname"]].(string); !ok || clean(group) == "" {
+				continue
+			} else {
+				member.Group = clean(group)
+			}
+
+			if k, ok := index["startdate"]; ok {
+				member.StartDate = date(record[k])
+			}
+
+			if k, ok := index["enddate"]; ok {
+				member.EndDate = date(record[k])
+			}
+
+			members = append(members, member)
+		}
+
+		return members, nil
+	}
+}
+
+func query(dbc *sql.DB, table string) ([]string, []record, error) {
+	sql := fmt.Sprintf(`SELECT * FROM %v;`, table)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	if prepared, err := dbc.Prepare(sql); err != nil {
+		return nil, nil, err
+	} else if rs, err := prepared.QueryContext(ctx); err != nil {
+		return nil, nil, err
+	} else if rs == nil {
+		return nil, nil, fmt.Errorf("invalid resultset (%v)", rs)
+	} else {
+		defer rs.Close()
+
+		if columns, err := rs.Columns(); err != nil {
+			return nil, nil, err
+		} else if types, err := rs.ColumnTypes(); err != nil {
+			return nil, nil, err
+		} else {
+			recordset := []record{}
+
+			for rs.Next() {
+				if record, err := row2record(rs, columns, types); err != nil {
+					return nil, nil, err
+				} else if record == nil {
+					return nil, nil, fmt.Errorf("invalid record (%v)", record)
+				} else {
+					recordset = append(recordset, record)
+				}
+			}
+
+			return columns, recordset, nil
+		}
+	}
+}
+
+func permission(v any) string {
+	if s, ok := v.(string); ok {
+		if s == "N" || s == "n" {
+			return "N"
+		} else if s == "Y" || s == "y" {
+			return "Y"
+		} else if p, err := strconv.ParseUint(s, 10, 8); err == nil {
+			return fmt.Sprintf("%v", p)
+		}
+	} else if p, ok := v.(int64); ok {
+		if p == 0 {
+			return "N"
+		} else if p == 1 {
+			return "Y"
+		} else if p > 1 && p < 255 {
+			return fmt.Sprintf("%v", p)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,68 @@
+package sqlite3
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+// GetHolidays retrieves the holidays from the holidays table. The Date column is required and the Name and
+// Controller columns are optional.
+func GetHolidays(dsn string, table string) ([]db.Holiday, error) {
+	if _, err := os.Stat(dsn); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("sqlite3 database %v does not exist", dsn)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+		return nil, err
+	} else if dbc == nil {
+		return nil, fmt.Errorf("invalid sqlite3 DB (%v)", dbc)
+	} else if columns, recordset, err := query(dbc, table); err != nil {
+		return nil, err
+	} else {
+		index := map[string]string{}
+		for _, c := range columns {
+			index[normalise(c)] = c
+		}
+
+		if _, ok := index["date"]; !ok {
+			return nil, fmt.Errorf("missing 'date' column")
+		}
+
+		holidays := []db.Holiday{}
+
+		for _, record := range recordset {
+			holiday := db.Holiday{
+				Date: date(record[index["date"]]),
+			}
+
+			if holiday.Date.IsZero() {
+				warnf("holidays: ignoring holiday with missing date")
+				continue
+			}
+
+			if k, ok := index["name"]; ok {
+				if name, ok := record[k].(string); ok {
+					holiday.Name = clean(name)
+				} else if name, ok := record[k].([]uint8); ok {
+					holiday.Name = clean(string(name))
+				}
+			}
+
+			if k, ok := index["controller"]; ok {
+				if controller, ok := integer(record[k]); ok && controller > 0 && controller <= math.MaxUint32 {
+					holiday.Controller = uint32(controller)
+				}
+			}
+
+			holidays = append(holidays, holiday)
+		}
+
+		return holidays, nil
+	}
+}
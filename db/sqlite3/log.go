@@ -3,9 +3,12 @@ package sqlite3
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/uhppoted/uhppoted-app-db/db"
@@ -38,46 +41,100 @@ func Log(dsn string, table string, recordset []db.LogRecord) (int, error) {
 }
 
 func appendToLog(dbc *sql.DB, tx *sql.Tx, table string, recordset []db.LogRecord) (int, error) {
-	count := 0
-	insert := []string{
-		fmt.Sprintf("INSERT INTO %v (Operation, Detail) VALUES (?,?);", table),
-		fmt.Sprintf("INSERT INTO %v (Operation, Controller, Detail) VALUES (?,?,?);", table),
-	}
+	runID := false
+	counters := false
+	numeric := map[string]string{}
+	duration := ""
+	timestamp := false
+
+	// ... get columns
+	if columns, err := getColumns(dbc, tx, table); err != nil {
+		return 0, err
+	} else {
+		for _, col := range columns {
+			if normalise(col) == "runid" {
+				runID = true
+			}
 
-	prepared := []*sql.Stmt{}
+			if normalise(col) == "counters" {
+				counters = true
+			}
 
-	for _, sql := range insert {
-		if stmt, err := dbc.Prepare(sql); err != nil {
-			return 0, err
-		} else {
-			prepared = append(prepared, stmt)
+			if slices.Contains(db.LogCounters, normalise(col)) {
+				numeric[normalise(col)] = col
+			}
+
+			if normalise(col) == "duration_ms" {
+				duration = col
+			}
+
+			if normalise(col) == "timestamp" {
+				timestamp = true
+			}
 		}
 	}
 
+	// ... append records
+	count := 0
+
 	for _, record := range recordset {
-		if record.Controller == 0 {
-			row := []any{record.Operation, record.Detail}
+		columns := []string{"Operation"}
+		row := []any{record.Operation}
 
-			if result, err := tx.Stmt(prepared[0]).Exec(row...); err != nil {
-				return 0, err
-			} else if id, err := result.LastInsertId(); err != nil {
+		if record.Controller != 0 {
+			columns = append(columns, "Controller")
+			row = append(row, record.Controller)
+		}
+
+		columns = append(columns, "Detail")
+		row = append(row, record.Detail)
+
+		if runID {
+			columns = append(columns, "RunID")
+			row = append(row, record.RunID)
+		}
+
+		if counters && len(record.Counters) > 0 {
+			if bytes, err := json.Marshal(record.Counters); err != nil {
 				return 0, err
 			} else {
-				count++
-				debugf("log: stored operations record @%v", id)
+				columns = append(columns, "Counters")
+				row = append(row, string(bytes))
 			}
-		} else {
-			row := []any{record.Operation, record.Controller, record.Detail}
+		}
 
-			if result, err := tx.Stmt(prepared[1]).Exec(row...); err != nil {
-				return 0, err
-			} else if id, err := result.LastInsertId(); err != nil {
-				return 0, err
-			} else {
-				count++
-				debugf("log: stored operations record for %v@%v", record.Controller, id)
+		for _, counter := range db.LogCounters {
+			if column, ok := numeric[counter]; ok {
+				columns = append(columns, column)
+
+				if v, ok := record.Counters[counter]; ok {
+					row = append(row, v)
+				} else {
+					row = append(row, nil)
+				}
 			}
 		}
+
+		if duration != "" {
+			columns = append(columns, duration)
+			row = append(row, record.Duration.Milliseconds())
+		}
+
+		if timestamp && !record.Timestamp.IsZero() {
+			columns = append(columns, "Timestamp")
+			row = append(row, record.Timestamp.Format("2006-01-02 15:04:05"))
+		}
+
+		sql := fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v);", table, strings.Join(columns, ", "), placeholders(len(columns)))
+
+		if result, err := tx.Exec(sql, row...); err != nil {
+			return 0, err
+		} else if id, err := result.LastInsertId(); err != nil {
+			return 0, err
+		} else {
+			count++
+			debugf("log: stored operations record for %v@%v", record.Controller, id)
+		}
 	}
 
 	return count, nil
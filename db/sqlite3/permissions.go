@@ -0,0 +1,122 @@
+package sqlite3
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+type check struct {
+	grant     string
+	statement string
+}
+
+// CheckPermissions tests the grants required for each of the table operations by executing a statement
+// that does not affect any rows, in a transaction that is always rolled back.
+// sqlite3 does not have grants, so the checks only fail if the database file cannot be read or written.
+func CheckPermissions(dsn string, table string, column string, operations []db.Operation) ([]db.Permission, error) {
+	if _, err := os.Stat(dsn); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("sqlite3 database %v does not exist", dsn)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+		return nil, err
+	} else if dbc == nil {
+		return nil, fmt.Errorf("invalid sqlite3 DB (%v)", dbc)
+	} else {
+		permissions := []db.Permission{}
+
+		for _, op := range operations {
+			if checks, err := checks(table, column, op); err != nil {
+				return nil, err
+			} else {
+				for _, c := range checks {
+					err := try(dbc, c.statement)
+
+					permissions = append(permissions, db.Permission{
+						Operation: op,
+						Grant:     c.grant,
+						Denied:    denied(err),
+						Err:       err,
+					})
+				}
+			}
+		}
+
+		return permissions, nil
+	}
+}
+
+func checks(table string, column string, op db.Operation) ([]check, error) {
+	if column == "" && (op == db.Insert || op == db.Upsert || op == db.Update) {
+		return nil, fmt.Errorf("missing %v column for %v check", table, op)
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %v (%v) SELECT NULL WHERE 1=2;", table, column)
+
+	switch op {
+	case db.Select:
+		return []check{
+			{"SELECT", fmt.Sprintf("SELECT * FROM %v WHERE 1=2;", table)},
+		}, nil
+
+	case db.Insert:
+		return []check{
+			{"INSERT", insert},
+		}, nil
+
+	case db.Upsert:
+		return []check{
+			{"INSERT", fmt.Sprintf("INSERT OR REPLACE INTO %v (%v) SELECT NULL WHERE 1=2;", table, column)},
+		}, nil
+
+	case db.Update:
+		return []check{
+			{"UPDATE", fmt.Sprintf("UPDATE %v SET %v=NULL WHERE 1=2;", table, column)},
+		}, nil
+
+	case db.Delete:
+		return []check{
+			{"DELETE", fmt.Sprintf("DELETE FROM %v WHERE 1=2;", table)},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported operation (%v)", op)
+	}
+}
+
+func try(dbc *sql.DB, statement string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	if tx, err := dbc.BeginTx(ctx, nil); err != nil {
+		return err
+	} else {
+		defer tx.Rollback()
+
+		_, err := tx.ExecContext(ctx, statement)
+
+		return err
+	}
+}
+
+// denied returns true if the error is a sqlite3 access permission, read-only database or authorization error.
+func denied(err error) bool {
+	var e sqlite3.Error
+
+	if errors.As(err, &e) {
+		return e.Code == sqlite3.ErrPerm || e.Code == sqlite3.ErrReadonly || e.Code == sqlite3.ErrAuth
+	}
+
+	return false
+}
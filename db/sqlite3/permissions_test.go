@@ -0,0 +1,65 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestCheckPermissions(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "acl.db")
+
+	if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+		t.Fatalf("%v", err)
+	} else if _, err := dbc.Exec(`CREATE TABLE ACL (CardNumber INTEGER, Status TEXT);`); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		dbc.Close()
+	}
+
+	tests := []struct {
+		name      string
+		column    string
+		operation db.Operation
+		grant     string
+		denied    bool
+		failed    bool
+		err       bool
+	}{
+		{"select", "", db.Select, "SELECT", false, false, false},
+		{"update", "Status", db.Update, "UPDATE", false, false, false},
+		{"update with unknown column", "CardStatus", db.Update, "UPDATE", false, true, false},
+		{"unsupported operation", "", db.Operation("TRUNCATE"), "", false, false, true},
+	}
+
+	for _, test := range tests {
+		permissions, err := CheckPermissions(dsn, "ACL", test.column, []db.Operation{test.operation})
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected error, got %v", test.name, permissions)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%v: unexpected error (%v)", test.name, err)
+		}
+
+		if len(permissions) != 1 {
+			t.Fatalf("%v: expected 1 permission, got %v", test.name, permissions)
+		}
+
+		p := permissions[0]
+		if p.Operation != test.operation || p.Grant != test.grant {
+			t.Errorf("%v: incorrect permission - expected:%v/%v, got:%v/%v", test.name, test.operation, test.grant, p.Operation, p.Grant)
+		}
+
+		if p.Denied != test.denied {
+			t.Errorf("%v: incorrect permission status - expected denied:%v, got:%v (%v)", test.name, test.denied, p.Denied, p.Err)
+		}
+
+		if (p.Err != nil) != (test.denied || test.failed) {
+			t.Errorf("%v: incorrect permission error - got:%v", test.name, p.Err)
+		}
+	}
+}
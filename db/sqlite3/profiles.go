@@ -0,0 +1,206 @@
+package sqlite3
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	core "github.com/uhppoted/uhppote-core/types"
+)
+
+func GetTimeProfiles(dsn string, table string) ([]core.TimeProfile, error) {
+	if _, err := os.Stat(dsn); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("sqlite3 database %v does not exist", dsn)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+		return nil, err
+	} else if dbc == nil {
+		return nil, fmt.Errorf("invalid sqlite3 DB (%v)", dbc)
+	} else if columns, recordset, err := query(dbc, table); err != nil {
+		return nil, err
+	} else {
+		index := map[string]string{}
+		for _, c := range columns {
+			index[normalise(c)] = c
+		}
+
+		if _, ok := index["profileid"]; !ok {
+			return nil, fmt.Errorf("missing 'profile ID' column")
+		}
+
+		weekdays := map[string]time.Weekday{
+			"monday":    time.Monday,
+			"tuesday":   time.Tuesday,
+			"wednesday": time.Wednesday,
+			"thursday":  time.Thursday,
+			"friday":    time.Friday,
+			"saturday":  time.Saturday,
+			"sunday":    time.Sunday,
+		}
+
+		profiles := []core.TimeProfile{}
+
+		for _, record := range recordset {
+			profile := core.TimeProfile{
+				Weekdays: core.Weekdays{},
+				Segments: core.Segments{},
+			}
+
+			if id, ok := integer(record[index["profileid"]]); !ok {
+				continue
+			} else if id < 2 || id > 254 {
+				warnf("profiles: invalid time profile ID (%v)", id)
+				continue
+			} else {
+				profile.ID = uint8(id)
+			}
+
+			if k, ok := index["startdate"]; ok {
+				profile.From = date(record[k])
+			}
+
+			if k, ok := index["enddate"]; ok {
+				profile.To = date(record[k])
+			}
+
+			for day, weekday := range weekdays {
+				if k, ok := index[day]; ok {
+					profile.Weekdays[weekday] = enabled(record[k])
+				}
+			}
+
+			for _, segment := range []uint8{1, 2, 3} {
+				start := core.HHmm{}
+				end := core.HHmm{}
+
+				if k, ok := index[fmt.Sprintf("segment%vstart", segment)]; ok {
+					start = hhmm(record[k])
+				}
+
+				if k, ok := index[fmt.Sprintf("segment%vend", segment)]; ok {
+					end = hhmm(record[k])
+				}
+
+				profile.Segments[segment] = core.Segment{
+					Start: start,
+					End:   end,
+				}
+			}
+
+			if k, ok := index["linkedprofileid"]; ok {
+				if linked, ok := integer(record[k]); ok && linked > 1 && linked < 255 {
+					profile.LinkedProfileID = uint8(linked)
+				}
+			}
+
+			if profile.From.IsZero() || profile.To.IsZero() {
+				warnf("profiles: ignoring time profile %v with missing start or end date", profile.ID)
+			} else {
+				profiles = append(profiles, profile)
+			}
+		}
+
+		return profiles, nil
+	}
+}
+
+func integer(v any) (int64, bool) {
+	switch u := v.(type) {
+	case int64:
+		return u, true
+
+	case []uint8:
+		if i, err := strconv.ParseInt(clean(string(u)), 10, 64); err == nil {
+			return i, true
+		}
+
+	case string:
+		if i, err := strconv.ParseInt(clean(u), 10, 64); err == nil {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func enabled(v any) bool {
+	switch u := v.(type) {
+	case bool:
+		return u
+
+	case int64:
+		return u != 0
+
+	case []uint8:
+		return enabled(string(u))
+
+	case string:
+		switch strings.ToLower(clean(u)) {
+		case "y", "yes", "1", "true":
+			return true
+		}
+	}
+
+	return false
+}
+
+func date(v any) core.Date {
+	s := ""
+
+	switch u := v.(type) {
+	case time.Time:
+		s = u.Format("2006-01-02")
+
+	case []uint8:
+		s = clean(string(u))
+
+	case string:
+		s = clean(u)
+	}
+
+	if s != "" {
+		if d, err := core.ParseDate(s); err != nil {
+			warnf("profiles: invalid date (%v)", s)
+		} else {
+			return d
+		}
+	}
+
+	return core.Date{}
+}
+
+func hhmm(v any) core.HHmm {
+	s := ""
+
+	switch u := v.(type) {
+	case time.Time:
+		return core.HHmmFromTime(u)
+
+	case []uint8:
+		s = clean(string(u))
+
+	case string:
+		s = clean(u)
+	}
+
+	// ... TIME columns are returned as HH:mm:ss
+	if len(s) == 8 {
+		s = s[:5]
+	}
+
+	if s != "" {
+		if t, err := core.HHmmFromString(s); err != nil {
+			warnf("profiles: invalid time (%v)", s)
+		} else if t != nil {
+			return *t
+		}
+	}
+
+	return core.HHmm{}
+}
@@ -10,10 +10,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/uhppoted/uhppoted-app-db/db"
 	lib "github.com/uhppoted/uhppoted-lib/acl"
 )
 
-func PutACL(dsn string, table string, recordset lib.Table, withPIN bool) (int, error) {
+func PutACL(dsn string, table string, recordset lib.Table, mapping db.Columns, withPIN bool) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
 	defer cancel()
@@ -32,7 +33,7 @@ func PutACL(dsn string, table string, recordset lib.Table, withPIN bool) (int, e
 		return 0, err
 	} else if _, err := clear(dbc, tx, table); err != nil {
 		return 0, err
-	} else if count, err := insert(dbc, tx, table, recordset, withPIN); err != nil {
+	} else if count, err := insert(dbc, tx, table, recordset, mapping, withPIN); err != nil {
 		return 0, err
 	} else if err := tx.Commit(); err != nil {
 		return 0, err
@@ -55,24 +56,40 @@ func clear(dbc *sql.DB, tx *sql.Tx, table string) (int64, error) {
 	}
 }
 
-func insert(dbc *sql.DB, tx *sql.Tx, table string, recordset lib.Table, withPIN bool) (int, error) {
-	columns := []string{"CardNumber", "StartDate", "EndDate"}
+func insert(dbc *sql.DB, tx *sql.Tx, table string, recordset lib.Table, mapping db.Columns, withPIN bool) (int, error) {
+	columns := []string{mapping.CardNumber, mapping.StartDate, mapping.EndDate}
 	index := map[string]int{}
 
+	keys := struct {
+		cardnumber string
+		pin        string
+		startdate  string
+		enddate    string
+		status     string
+		scope      string
+	}{
+		cardnumber: normalise(mapping.CardNumber),
+		pin:        normalise(mapping.PIN),
+		startdate:  normalise(mapping.StartDate),
+		enddate:    normalise(mapping.EndDate),
+		status:     normalise(mapping.Status),
+		scope:      normalise(mapping.Controllers),
+	}
+
 	for i, h := range recordset.Header {
 		ix := i
 		if col := normalise(h); col == "cardnumber" {
-			index["cardnumber"] = ix + 1
+			index[keys.cardnumber] = ix + 1
 			break
 		}
 	}
 
 	if withPIN {
-		columns = []string{"CardNumber", "PIN", "StartDate", "EndDate"}
+		columns = []string{mapping.CardNumber, mapping.PIN, mapping.StartDate, mapping.EndDate}
 		for i, h := range recordset.Header {
 			ix := i
 			if col := normalise(h); col == "pin" {
-				index["pin"] = ix + 1
+				index[keys.pin] = ix + 1
 				break
 			}
 		}
@@ -81,7 +98,7 @@ func insert(dbc *sql.DB, tx *sql.Tx, table string, recordset lib.Table, withPIN
 	for i, h := range recordset.Header {
 		ix := i
 		if col := normalise(h); col == "from" {
-			index["startdate"] = ix + 1
+			index[keys.startdate] = ix + 1
 			break
 		}
 	}
@@ -89,18 +106,50 @@ func insert(dbc *sql.DB, tx *sql.Tx, table string, recordset lib.Table, withPIN
 	for i, h := range recordset.Header {
 		ix := i
 		if col := normalise(h); col == "to" {
-			index["enddate"] = ix + 1
+			index[keys.enddate] = ix + 1
 			break
 		}
 	}
 
+	// ... optional card status
+	if mapping.Status != "" {
+		for i, h := range recordset.Header {
+			ix := i
+			if col := normalise(h); col == "status" || col == keys.status {
+				columns = append(columns, mapping.Status)
+				index[keys.status] = ix + 1
+				break
+			}
+		}
+	}
+
+	// ... optional controller scope
+	if mapping.Controllers != "" {
+		for i, h := range recordset.Header {
+			ix := i
+			if col := normalise(h); col == "controllers" || col == keys.scope {
+				columns = append(columns, mapping.Controllers)
+				index[keys.scope] = ix + 1
+				break
+			}
+		}
+	}
+
 	for i, h := range recordset.Header {
 		ix := i
 		col := normalise(h)
 
+		if col == "status" || col == keys.status || col == "controllers" || col == keys.scope {
+			continue
+		}
+
 		if col != "name" && col != "cardnumber" && col != "from" && col != "to" && col != "pin" {
-			columns = append(columns, strings.ReplaceAll(h, " ", ""))
-			index[col] = ix + 1
+			if column, ok := mapping.Column(h); !ok {
+				warnf("put-acl: no ACL table column for door '%v'", h)
+			} else {
+				columns = append(columns, column)
+				index[normalise(column)] = ix + 1
+			}
 		}
 	}
 
@@ -132,11 +181,15 @@ func insert(dbc *sql.DB, tx *sql.Tx, table string, recordset lib.Table, withPIN
 			for i, col := range columns {
 				ix := index[normalise(col)] - 1
 
-				if normalise(col) == "pin" {
+				if normalise(col) == keys.pin {
 					if row[ix] == "" {
 						record[i] = 0
-					} else if pin, err := strconv.ParseUint(row[ix], 10, 16); err != nil {
+					} else if db.IsEncryptedPIN(row[ix]) {
+						record[i] = row[ix]
+					} else if pin, err := strconv.ParseUint(row[ix], 10, 32); err != nil {
 						return 0, err
+					} else if pin > db.MaxPIN {
+						return 0, fmt.Errorf("invalid PIN (%v) for card %v", pin, row[index[keys.cardnumber]-1])
 					} else {
 						record[i] = pin
 					}
@@ -151,7 +204,7 @@ func insert(dbc *sql.DB, tx *sql.Tx, table string, recordset lib.Table, withPIN
 				return 0, err
 			} else {
 				count++
-				debugf("put-acl: stored card %v@%v", row[index["cardnumber"]], id)
+				debugf("put-acl: stored card %v@%v", row[index[keys.cardnumber]-1], id)
 			}
 		}
 	}
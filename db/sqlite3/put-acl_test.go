@@ -0,0 +1,80 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	lib "github.com/uhppoted/uhppoted-lib/acl"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestPutACLWithStatusAndControllers(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "acl.db")
+
+	if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+		t.Fatalf("%v", err)
+	} else if _, err := dbc.Exec(`CREATE TABLE ACL (
+	    CardNumber  INTEGER UNIQUE ON CONFLICT REPLACE,
+	    StartDate   TEXT    DEFAULT '',
+	    EndDate     TEXT    DEFAULT '',
+	    GreatHall   INTEGER DEFAULT 0,
+	    Gryffindor  INTEGER DEFAULT 0,
+	    CardStatus  TEXT    DEFAULT '',
+	    Scope       TEXT    DEFAULT ''
+	);`); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		dbc.Close()
+	}
+
+	mapping := db.Columns{
+		CardNumber:  "CardNumber",
+		PIN:         "PIN",
+		StartDate:   "StartDate",
+		EndDate:     "EndDate",
+		Status:      "CardStatus",
+		Controllers: "Scope",
+		Doors: map[string]string{
+			"GreatHall":  "Great Hall",
+			"Gryffindor": "Gryffindor",
+		},
+	}
+
+	tests := []struct {
+		name   string
+		header []string
+	}{
+		{"ACL table header", []string{"Card Number", "From", "To", "Great Hall", "Gryffindor", "Status", "Controllers"}},
+		{"mapped column header", []string{"Card Number", "From", "To", "Great Hall", "Gryffindor", "CardStatus", "Scope"}},
+	}
+
+	expected := lib.Table{
+		Header: []string{"Card Number", "From", "To", "Great Hall", "Gryffindor", "Status", "Controllers"},
+		Records: [][]string{
+			{"10058400", "2025-01-01", "2025-12-31", "Y", "N", "active", ""},
+			{"10058401", "2025-01-01", "2025-12-31", "N", "Y", "lost", "405419896"},
+		},
+	}
+
+	for _, test := range tests {
+		acl := lib.Table{
+			Header:  test.header,
+			Records: expected.Records,
+		}
+
+		if N, err := PutACL(dsn, "ACL", acl, mapping, false); err != nil {
+			t.Fatalf("%v: error storing ACL (%v)", test.name, err)
+		} else if N != 2 {
+			t.Errorf("%v: incorrect record count - expected:2, got:%v", test.name, N)
+		}
+
+		if table, err := GetACL(dsn, "ACL", mapping, false); err != nil {
+			t.Fatalf("%v: error retrieving ACL (%v)", test.name, err)
+		} else if !reflect.DeepEqual(*table, expected) {
+			t.Errorf("%v: incorrect ACL\n   expected:%q\n   got:     %q", test.name, expected, *table)
+		}
+	}
+}
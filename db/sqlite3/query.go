@@ -0,0 +1,144 @@
+package sqlite3
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func Query(dsn string, table string, filter db.Filter) ([]db.Record, error) {
+	if _, err := os.Stat(dsn); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("sqlite3 database %v does not exist", dsn)
+	} else if err != nil {
+		return nil, err
+	}
+
+	where := []string{}
+	args := []any{}
+
+	for _, column := range slices.Sorted(maps.Keys(filter.Where)) {
+		if !regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`).MatchString(column) {
+			return nil, fmt.Errorf("invalid column name '%v'", column)
+		}
+
+		args = append(args, filter.Where[column])
+		where = append(where, fmt.Sprintf("%v=%v", column, placeholder(len(args))))
+	}
+
+	for _, column := range slices.Sorted(maps.Keys(filter.After)) {
+		if !regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`).MatchString(column) {
+			return nil, fmt.Errorf("invalid column name '%v'", column)
+		}
+
+		args = append(args, filter.After[column])
+		where = append(where, fmt.Sprintf("%v>%v", column, placeholder(len(args))))
+	}
+
+	if !filter.From.IsZero() {
+		args = append(args, filter.From.Format("2006-01-02 15:04:05"))
+		where = append(where, fmt.Sprintf("Timestamp>=%v", placeholder(len(args))))
+	}
+
+	if !filter.To.IsZero() {
+		args = append(args, filter.To.Format("2006-01-02 15:04:05"))
+		where = append(where, fmt.Sprintf("Timestamp<=%v", placeholder(len(args))))
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM %v`, table)
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	query += " ORDER BY Timestamp DESC"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %v", filter.Limit)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	if dbc, err := open(dsn, MaxLifetime, MaxIdle, MaxOpen); err != nil {
+		return nil, err
+	} else if dbc == nil {
+		return nil, fmt.Errorf("invalid sqlite3 DB (%v)", dbc)
+	} else if prepared, err := dbc.Prepare(query + ";"); err != nil {
+		return nil, err
+	} else if rs, err := prepared.QueryContext(ctx, args...); err != nil {
+		return nil, err
+	} else if rs == nil {
+		return nil, fmt.Errorf("invalid resultset (%v)", rs)
+	} else {
+		defer rs.Close()
+
+		if columns, err := rs.Columns(); err != nil {
+			return nil, err
+		} else {
+			records := []db.Record{}
+
+			for rs.Next() {
+				if record, err := scan(rs, columns); err != nil {
+					return nil, err
+				} else {
+					records = append(records, record)
+				}
+			}
+
+			return records, nil
+		}
+	}
+}
+
+// scan converts a row of any column types to a record, with text and timestamp values converted
+// to strings.
+func scan(rows *sql.Rows, columns []string) (db.Record, error) {
+	values := make([]any, len(columns))
+	pointers := make([]any, len(values))
+
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+
+	record := db.Record{}
+	for i, column := range columns {
+		switch v := values[i].(type) {
+		case []uint8:
+			record[column] = string(v)
+
+		case time.Time:
+			record[column] = v.Format("2006-01-02 15:04:05")
+
+		default:
+			record[column] = v
+		}
+	}
+
+	return record, nil
+}
+
+func placeholder(n int) string {
+	return "?"
+}
+
+func placeholders(N int) string {
+	list := []string{}
+	for i := 1; i <= N; i++ {
+		list = append(list, placeholder(i))
+	}
+
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,63 @@
+package sqlite3
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestQueryWithAfter(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "events.db")
+
+	if dbc, err := sql.Open("sqlite3", dsn); err != nil {
+		t.Fatalf("%v", err)
+	} else if _, err := dbc.Exec(`CREATE TABLE Events (
+	    Controller INTEGER NOT NULL,
+	    EventIndex INTEGER NOT NULL,
+	    Timestamp  DATETIME NULL
+	);
+	INSERT INTO Events VALUES (405419896, 1, '2025-01-01 12:00:01');
+	INSERT INTO Events VALUES (405419896, 2, '2025-01-01 12:00:02');
+	INSERT INTO Events VALUES (405419896, 3, '2025-01-01 12:00:03');
+	INSERT INTO Events VALUES (303986753, 4, '2025-01-01 12:00:04');`); err != nil {
+		t.Fatalf("%v", err)
+	} else {
+		dbc.Close()
+	}
+
+	tests := []struct {
+		name     string
+		filter   db.Filter
+		expected []string
+		err      bool
+	}{
+		{"after", db.Filter{After: map[string]any{"EventIndex": 1}}, []string{"4", "3", "2"}, false},
+		{"where and after", db.Filter{Where: map[string]any{"Controller": 405419896}, After: map[string]any{"EventIndex": 2}}, []string{"3"}, false},
+		{"invalid column", db.Filter{After: map[string]any{"EventIndex;": 1}}, nil, true},
+	}
+
+	for _, test := range tests {
+		records, err := Query(dsn, "Events", test.filter)
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected error, got %v", test.name, records)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		indices := []string{}
+		for _, r := range records {
+			indices = append(indices, fmt.Sprintf("%v", r["EventIndex"]))
+		}
+
+		if !reflect.DeepEqual(indices, test.expected) {
+			t.Errorf("%v: incorrect events\n   expected:%v\n   got:     %v", test.name, test.expected, indices)
+		}
+	}
+}
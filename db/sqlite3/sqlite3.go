@@ -32,18 +32,22 @@ func NewDB(dsn string) db.DB {
 	}
 }
 
-func (d dbi) GetACL(table string, withPIN bool) (*lib.Table, error) {
-	return GetACL(d.dsn, table, withPIN)
+func (d dbi) GetACL(table string, columns db.Columns, withPIN bool) (*lib.Table, error) {
+	return GetACL(d.dsn, table, columns, withPIN)
 }
 
-func (d dbi) PutACL(table string, acl lib.Table, withPIN bool) (int, error) {
-	return PutACL(d.dsn, table, acl, withPIN)
+func (d dbi) PutACL(table string, acl lib.Table, columns db.Columns, withPIN bool) (int, error) {
+	return PutACL(d.dsn, table, acl, columns, withPIN)
 }
 
 func (d dbi) GetEvents(table string, controller uint32) ([]uint32, error) {
 	return GetEvents(d.dsn, table, controller)
 }
 
+func (d dbi) GetControllers(table string) ([]uint32, error) {
+	return GetControllers(d.dsn, table)
+}
+
 func (d dbi) PutEvents(table string, events []core.Event) (int, error) {
 	return PutEvents(d.dsn, table, events)
 }
@@ -56,6 +60,34 @@ func (d dbi) Log(table string, rs []db.LogRecord) (int, error) {
 	return Log(d.dsn, table, rs)
 }
 
+func (d dbi) GetGroups(table string, columns db.Columns) ([]db.Group, error) {
+	return GetGroups(d.dsn, table, columns)
+}
+
+func (d dbi) GetGroupMembers(table string) ([]db.GroupMember, error) {
+	return GetGroupMembers(d.dsn, table)
+}
+
+func (d dbi) GetTimeProfiles(table string) ([]core.TimeProfile, error) {
+	return GetTimeProfiles(d.dsn, table)
+}
+
+func (d dbi) GetHolidays(table string) ([]db.Holiday, error) {
+	return GetHolidays(d.dsn, table)
+}
+
+func (d dbi) Query(table string, filter db.Filter) ([]db.Record, error) {
+	return Query(d.dsn, table, filter)
+}
+
+func (d dbi) CheckPermissions(table string, column string, operations []db.Operation) ([]db.Permission, error) {
+	return CheckPermissions(d.dsn, table, column, operations)
+}
+
+func (d dbi) ExpireCards(table string, columns db.Columns, cards []uint32, status string) (int, error) {
+	return ExpireCards(d.dsn, table, columns, cards, status)
+}
+
 func open(path string, maxLifetime time.Duration, maxOpen int, maxIdle int) (*sql.DB, error) {
 	dbc, err := sql.Open("sqlite3", path)
 	if err != nil {
@@ -0,0 +1,31 @@
+package sqlite3
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uhppoted/uhppoted-app-db/db"
+)
+
+func TestMakeTableWithPIN(t *testing.T) {
+	columns := []string{"CardNumber", "PIN", "StartDate", "EndDate", "GreatHall"}
+
+	recordset := []record{
+		{"CardNumber": int64(10058400), "PIN": int64(7531), "StartDate": "2025-01-01", "EndDate": "2025-12-31", "GreatHall": int64(1)},
+		{"CardNumber": int64(10058401), "PIN": int64(1000000), "StartDate": "2025-01-01", "EndDate": "2025-12-31", "GreatHall": int64(1)},
+		{"CardNumber": int64(10058402), "PIN": int64(-1), "StartDate": "2025-01-01", "EndDate": "2025-12-31", "GreatHall": int64(1)},
+		{"CardNumber": int64(10058403), "PIN": "enc:abcdef", "StartDate": "2025-01-01", "EndDate": "2025-12-31", "GreatHall": int64(1)},
+	}
+
+	expected := [][]string{
+		{"10058400", "7531", "2025-01-01", "2025-12-31", "Y"},
+		{"10058403", "enc:abcdef", "2025-01-01", "2025-12-31", "Y"},
+	}
+
+	// ... cards with invalid PINs are skipped
+	if table, err := makeTable(columns, recordset, db.DefaultColumns, true); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if !reflect.DeepEqual(table.Records, expected) {
+		t.Errorf("incorrect records\n   expected:%q\n   got:     %q", expected, table.Records)
+	}
+}
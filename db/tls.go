@@ -0,0 +1,130 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLS holds the TLS settings for a MySQL, PostgreSQL or SQL Server connection. The TLS modes are:
+//
+//   - disable:     connect without TLS
+//   - require:     connect with TLS but don't verify the server certificate
+//   - verify-ca:   connect with TLS and verify that the server certificate is signed by the CA
+//   - verify-full: connect with TLS and verify the server certificate and server name
+//
+// An empty mode leaves the TLS settings in the DSN unchanged.
+type TLS struct {
+	Mode        string
+	CA          string
+	Certificate string
+	Key         string
+	ServerName  string
+}
+
+const (
+	TLSDisable    = "disable"
+	TLSRequire    = "require"
+	TLSVerifyCA   = "verify-ca"
+	TLSVerifyFull = "verify-full"
+)
+
+func (t TLS) Validate() error {
+	switch t.Mode {
+	case "", TLSDisable, TLSRequire, TLSVerifyCA, TLSVerifyFull:
+	default:
+		return fmt.Errorf("invalid TLS mode (%v)", t.Mode)
+	}
+
+	if t.Mode == "" && (t.CA != "" || t.Certificate != "" || t.Key != "" || t.ServerName != "") {
+		return fmt.Errorf("TLS settings require a TLS mode")
+	}
+
+	if (t.Certificate == "") != (t.Key == "") {
+		return fmt.Errorf("TLS client certificate requires both certificate and key files")
+	}
+
+	return nil
+}
+
+// Config returns the tls.Config for the TLS mode, CA bundle and client certificate. The server name
+// defaults to the host if not set explicitly. Returns nil if the mode is 'disable'.
+func (t TLS) Config(host string) (*tls.Config, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	} else if t.Mode == TLSDisable {
+		return nil, nil
+	}
+
+	config := tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: host,
+	}
+
+	if t.ServerName != "" {
+		config.ServerName = t.ServerName
+	}
+
+	if t.CA != "" {
+		pool := x509.NewCertPool()
+
+		if bytes, err := os.ReadFile(t.CA); err != nil {
+			return nil, err
+		} else if !pool.AppendCertsFromPEM(bytes) {
+			return nil, fmt.Errorf("no valid certificates in CA file %v", t.CA)
+		} else {
+			config.RootCAs = pool
+		}
+	}
+
+	if t.Certificate != "" {
+		if certificate, err := tls.LoadX509KeyPair(t.Certificate, t.Key); err != nil {
+			return nil, err
+		} else {
+			config.Certificates = []tls.Certificate{certificate}
+		}
+	}
+
+	switch t.Mode {
+	case TLSRequire:
+		config.InsecureSkipVerify = true
+
+	case TLSVerifyCA:
+		// ... verify the certificate chain but not the server name
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = func(raw [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(raw, config.RootCAs)
+		}
+	}
+
+	return &config, nil
+}
+
+func verifyChain(raw [][]byte, roots *x509.CertPool) error {
+	certificates := []*x509.Certificate{}
+	for _, bytes := range raw {
+		if certificate, err := x509.ParseCertificate(bytes); err != nil {
+			return err
+		} else {
+			certificates = append(certificates, certificate)
+		}
+	}
+
+	if len(certificates) == 0 {
+		return fmt.Errorf("missing server certificate")
+	}
+
+	options := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+
+	for _, certificate := range certificates[1:] {
+		options.Intermediates.AddCert(certificate)
+	}
+
+	_, err := certificates[0].Verify(options)
+
+	return err
+}
@@ -1,7 +1,15 @@
 package log
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	syslog "log"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/uhppoted/uhppoted-lib/log"
 )
@@ -12,38 +20,226 @@ func (l LogLevel) String() string {
 	return []string{"NONE", "DEBUG", "INFO", "WARN", "ERROR"}[l]
 }
 
+const (
+	none LogLevel = iota
+	debug
+	info
+	warn
+	errors
+)
+
+var state = struct {
+	sync.RWMutex
+	format    string
+	runID     string
+	debugging bool
+	level     LogLevel
+	logger    *syslog.Logger
+}{
+	format: "text",
+	runID:  NewRunID(),
+	level:  info,
+	logger: syslog.New(os.Stdout, "", 0),
+}
+
 func SetDebug(enabled bool) {
+	state.Lock()
+	state.debugging = enabled
+	state.Unlock()
+
 	log.SetDebug(enabled)
 }
 
 func SetLevel(level string) {
+	state.Lock()
+	switch level {
+	case "none":
+		state.level = none
+	case "debug":
+		state.level = debug
+	case "info":
+		state.level = info
+	case "warn":
+		state.level = warn
+	case "error":
+		state.level = errors
+	}
+	state.Unlock()
+
 	log.SetLevel(level)
 }
 
+// SetLogger sets the logger for both the text and JSON log formats.
 func SetLogger(logger *syslog.Logger) {
+	state.Lock()
+	state.logger = logger
+	state.Unlock()
+
 	log.SetLogger(logger)
 }
 
+// SetFormat sets the log format to either 'text' (the default) or 'json'. JSON log lines include the
+// run ID.
+func SetFormat(format string) error {
+	switch format {
+	case "text", "json":
+		state.Lock()
+		state.format = format
+		state.Unlock()
+
+		return nil
+
+	default:
+		return fmt.Errorf("invalid log format (%v)", format)
+	}
+}
+
+// NewRunID returns a random 16 character hex run ID.
+func NewRunID() string {
+	bytes := make([]byte, 8)
+
+	rand.Read(bytes)
+
+	return hex.EncodeToString(bytes)
+}
+
+// RunID returns the process run ID attached to the JSON log lines and the audit trail and operations log
+// records.
+func RunID() string {
+	state.RLock()
+	defer state.RUnlock()
+
+	return state.runID
+}
+
+// Run writes the log lines for a single run (e.g. a run triggered by the 'serve' command) with the run ID
+// of the run rather than the process run ID. A blank Run logs with the process run ID.
+type Run string
+
+// ID returns the run ID, defaulting to the process run ID.
+func (r Run) ID() string {
+	if r == "" {
+		return RunID()
+	}
+
+	return string(r)
+}
+
+func (r Run) Debugf(format string, args ...any) {
+	if isJSON() {
+		state.RLock()
+		enabled := state.debugging || state.level == debug
+		state.RUnlock()
+
+		if enabled {
+			emit(r.ID(), debug, format, args...)
+		}
+	} else {
+		log.Debugf(format, args...)
+	}
+}
+
+func (r Run) Infof(format string, args ...any) {
+	if isJSON() {
+		state.RLock()
+		enabled := state.level < warn
+		state.RUnlock()
+
+		if enabled {
+			emit(r.ID(), info, format, args...)
+		}
+	} else {
+		log.Infof(format, args...)
+	}
+}
+
+func (r Run) Warnf(format string, args ...any) {
+	if isJSON() {
+		state.RLock()
+		enabled := state.level < errors
+		state.RUnlock()
+
+		if enabled {
+			emit(r.ID(), warn, format, args...)
+		}
+	} else {
+		log.Warnf(format, args...)
+	}
+}
+
+func (r Run) Errorf(format string, args ...any) {
+	if isJSON() {
+		emit(r.ID(), errors, format, args...)
+	} else {
+		log.Errorf(format, args...)
+	}
+}
+
 func SetFatalHook(f func()) {
 	log.AddFatalHook(f)
 }
 
 func Debugf(format string, args ...any) {
-	log.Debugf(format, args...)
+	Run("").Debugf(format, args...)
 }
 
 func Infof(format string, args ...any) {
-	log.Infof(format, args...)
+	Run("").Infof(format, args...)
 }
 
 func Warnf(format string, args ...any) {
-	log.Warnf(format, args...)
+	Run("").Warnf(format, args...)
 }
 
 func Errorf(format string, args ...any) {
-	log.Errorf(format, args...)
+	Run("").Errorf(format, args...)
 }
 
 func Fatalf(format string, args ...any) {
+	if isJSON() {
+		emit(RunID(), errors, format, args...)
+	}
+
 	log.Fatalf(format, args...)
 }
+
+func isJSON() bool {
+	state.RLock()
+	defer state.RUnlock()
+
+	return state.format == "json"
+}
+
+// emit writes a JSON log line. The tag is split off the message since the log functions throughout
+// the application are invoked with a "<tag> <message>" format.
+func emit(runID string, level LogLevel, format string, args ...any) {
+	message := strings.TrimSpace(fmt.Sprintf(format, args...))
+	tag := ""
+
+	if before, after, ok := strings.Cut(message, " "); ok {
+		tag = before
+		message = strings.TrimSpace(after)
+	}
+
+	line := struct {
+		Timestamp string `json:"timestamp"`
+		Level     string `json:"level"`
+		RunID     string `json:"run-id"`
+		Tag       string `json:"tag,omitempty"`
+		Message   string `json:"message"`
+	}{
+		Timestamp: time.Now().Format(time.RFC3339Nano),
+		Level:     level.String(),
+		RunID:     runID,
+		Tag:       tag,
+		Message:   message,
+	}
+
+	if bytes, err := json.Marshal(line); err == nil {
+		state.RLock()
+		logger := state.logger
+		state.RUnlock()
+
+		logger.Println(string(bytes))
+	}
+}
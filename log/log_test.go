@@ -0,0 +1,106 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	syslog "log"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRunID(t *testing.T) {
+	var b bytes.Buffer
+
+	logger := state.logger
+	format := state.format
+
+	defer func() {
+		state.logger = logger
+		state.format = format
+	}()
+
+	state.logger = syslog.New(&b, "", 0)
+	SetFormat("json")
+
+	runs := map[string]Run{
+		"load-acl":    Run(NewRunID()),
+		"compare-acl": Run(NewRunID()),
+		"get-events":  Run(NewRunID()),
+	}
+
+	var wg sync.WaitGroup
+	for tag, run := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				run.Infof("%v run", tag)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	Infof("serve listening")
+
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		var v struct {
+			RunID string `json:"run-id"`
+			Tag   string `json:"tag"`
+		}
+
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Fatalf("invalid JSON log line %q (%v)", line, err)
+		}
+
+		expected := RunID()
+		if run, ok := runs[v.Tag]; ok {
+			expected = string(run)
+		}
+
+		if v.RunID != expected {
+			t.Errorf("%v: incorrect run ID - expected:%v, got:%v", v.Tag, expected, v.RunID)
+		}
+	}
+
+	if id := Run("").ID(); id != RunID() {
+		t.Errorf("incorrect default run ID - expected:%v, got:%v", RunID(), id)
+	}
+}
+
+func TestJSONDebug(t *testing.T) {
+	var b bytes.Buffer
+
+	logger := state.logger
+	format := state.format
+	level := state.level
+
+	defer func() {
+		SetLogger(logger)
+		SetLevel(strings.ToLower(level.String()))
+		state.format = format
+	}()
+
+	SetLogger(syslog.New(&b, "", 0))
+	SetFormat("json")
+
+	tests := []struct {
+		level    string
+		expected bool
+	}{
+		{"none", false},
+		{"info", false},
+		{"debug", true},
+	}
+
+	for _, test := range tests {
+		b.Reset()
+		SetLevel(test.level)
+		Debugf("test debug")
+
+		if logged := b.Len() > 0; logged != test.expected {
+			t.Errorf("%v: incorrect debug logging - expected:%v, got:%v", test.level, test.expected, logged)
+		}
+	}
+}
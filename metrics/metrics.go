@@ -0,0 +1,190 @@
+// Package metrics implements a minimal in-process metrics registry that is exposed in the Prometheus text
+// exposition format by the 'serve' command.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	EventsStored       = "uhppoted_app_db_events_stored_total"
+	LastEventIndex     = "uhppoted_app_db_last_event_index"
+	LastEventTimestamp = "uhppoted_app_db_last_event_timestamp_seconds"
+	EventGaps          = "uhppoted_app_db_event_gaps"
+	GetEventsErrors    = "uhppoted_app_db_get_events_errors_total"
+	LoadACLCards       = "uhppoted_app_db_load_acl_cards_total"
+	LoadACLRuns        = "uhppoted_app_db_load_acl_runs_total"
+	CompareACLCards    = "uhppoted_app_db_compare_acl_discrepancies"
+	CompareACLRuns     = "uhppoted_app_db_compare_acl_runs_total"
+	DBLatency          = "uhppoted_app_db_db_operation_seconds"
+	DBErrors           = "uhppoted_app_db_db_operation_errors_total"
+	LockContention     = "uhppoted_app_db_lockfile_contention_total"
+)
+
+type Labels map[string]string
+
+type kind string
+
+const (
+	counter kind = "counter"
+	gauge   kind = "gauge"
+	summary kind = "summary"
+)
+
+type family struct {
+	kind   kind
+	help   string
+	series map[string]*series
+}
+
+type series struct {
+	labels string
+	value  float64
+	count  uint64
+}
+
+var registry = struct {
+	sync.Mutex
+	families map[string]*family
+}{
+	families: map[string]*family{
+		EventsStored:       {kind: counter, help: "Number of events stored to the events table"},
+		LastEventIndex:     {kind: gauge, help: "Index of the most recent event retrieved from the controller"},
+		LastEventTimestamp: {kind: gauge, help: "Timestamp (Unix seconds) of the most recent event retrieved from the controller"},
+		EventGaps:          {kind: gauge, help: "Number of events missing from the events table between the first and last stored event"},
+		GetEventsErrors:    {kind: counter, help: "Number of get-events controller errors"},
+		LoadACLCards:       {kind: counter, help: "Number of cards processed by load-acl, by result"},
+		LoadACLRuns:        {kind: counter, help: "Number of load-acl runs, by result"},
+		CompareACLCards:    {kind: gauge, help: "Number of discrepancies found by the most recent compare-acl, by type"},
+		CompareACLRuns:     {kind: counter, help: "Number of compare-acl runs, by result"},
+		DBLatency:          {kind: summary, help: "DB operation latency, by backend and operation"},
+		DBErrors:           {kind: counter, help: "Number of failed DB operations, by backend and operation"},
+		LockContention:     {kind: counter, help: "Number of operations that could not acquire the lockfile"},
+	},
+}
+
+// Add increments a counter.
+func Add(name string, labels Labels, v float64) {
+	update(name, labels, func(s *series) {
+		s.value += v
+	})
+}
+
+// Set sets a gauge to the value.
+func Set(name string, labels Labels, v float64) {
+	update(name, labels, func(s *series) {
+		s.value = v
+	})
+}
+
+// Observe adds an observation to a summary.
+func Observe(name string, labels Labels, v float64) {
+	update(name, labels, func(s *series) {
+		s.value += v
+		s.count++
+	})
+}
+
+// Write writes all the metrics in the Prometheus text exposition format.
+func Write(w io.Writer) error {
+	registry.Lock()
+	defer registry.Unlock()
+
+	names := []string{}
+	for k := range registry.families {
+		names = append(names, k)
+	}
+
+	slices.Sort(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := registry.families[name]
+
+		fmt.Fprintf(&b, "# HELP %v %v\n", name, f.help)
+		fmt.Fprintf(&b, "# TYPE %v %v\n", name, f.kind)
+
+		keys := []string{}
+		for k := range f.series {
+			keys = append(keys, k)
+		}
+
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			s := f.series[k]
+
+			if f.kind == summary {
+				fmt.Fprintf(&b, "%v_sum%v %v\n", name, s.labels, s.value)
+				fmt.Fprintf(&b, "%v_count%v %v\n", name, s.labels, s.count)
+			} else {
+				fmt.Fprintf(&b, "%v%v %v\n", name, s.labels, s.value)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// Handler returns an http.Handler that serves the metrics.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w)
+	})
+}
+
+func update(name string, labels Labels, f func(*series)) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	family, ok := registry.families[name]
+	if !ok {
+		return
+	}
+
+	if family.series == nil {
+		family.series = map[string]*series{}
+	}
+
+	key := format(labels)
+	s, ok := family.series[key]
+	if !ok {
+		s = &series{labels: key}
+		family.series[key] = s
+	}
+
+	f(s)
+}
+
+func format(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := []string{}
+	for k := range labels {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	list := []string{}
+	for _, k := range keys {
+		v := labels[k]
+		v = strings.ReplaceAll(v, `\`, `\\`)
+		v = strings.ReplaceAll(v, `"`, `\"`)
+		v = strings.ReplaceAll(v, "\n", `\n`)
+
+		list = append(list, fmt.Sprintf(`%v="%v"`, k, v))
+	}
+
+	return "{" + strings.Join(list, ",") + "}"
+}
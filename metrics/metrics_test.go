@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		labels   Labels
+		expected string
+	}{
+		{Labels{}, ""},
+		{Labels{"type": "missing", "controller": "405419896"}, `{controller="405419896",type="missing"}`},
+		{Labels{"detail": "a \"quoted\" \\ value\n"}, `{detail="a \"quoted\" \\ value\n"}`},
+	}
+
+	for _, test := range tests {
+		if v := format(test.labels); v != test.expected {
+			t.Errorf("incorrect labels - expected:%v, got:%v", test.expected, v)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	Add(EventsStored, Labels{"controller": "101"}, 1)
+	Add(EventsStored, Labels{"controller": "101"}, 2)
+	Set(CompareACLCards, Labels{"controller": "101", "type": "missing"}, 5)
+	Set(CompareACLCards, Labels{"controller": "101", "type": "missing"}, 3)
+	Observe(DBLatency, Labels{"backend": "test", "operation": "get-acl"}, 0.25)
+	Observe(DBLatency, Labels{"backend": "test", "operation": "get-acl"}, 0.5)
+	Add("uhppoted_app_db_unknown_total", Labels{"controller": "101"}, 1)
+
+	var b strings.Builder
+	if err := Write(&b); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := []string{
+		"# HELP uhppoted_app_db_events_stored_total Number of events stored to the events table",
+		"# TYPE uhppoted_app_db_events_stored_total counter",
+		`uhppoted_app_db_events_stored_total{controller="101"} 3`,
+		"# TYPE uhppoted_app_db_compare_acl_discrepancies gauge",
+		`uhppoted_app_db_compare_acl_discrepancies{controller="101",type="missing"} 3`,
+		"# TYPE uhppoted_app_db_db_operation_seconds summary",
+		`uhppoted_app_db_db_operation_seconds_sum{backend="test",operation="get-acl"} 0.75`,
+		`uhppoted_app_db_db_operation_seconds_count{backend="test",operation="get-acl"} 2`,
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("missing metrics line %q", line)
+		}
+	}
+
+	if strings.Contains(b.String(), "uhppoted_app_db_unknown_total") {
+		t.Errorf("unexpected unregistered metric\n%v", b.String())
+	}
+}
@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppoted-app-db/log"
+)
+
+// Config holds the notification settings. Notifications are only sent if an SMTP server or a webhook
+// URL is configured.
+type Config struct {
+	Threshold   int
+	QuietPeriod time.Duration
+	State       string
+	SMTP        SMTP
+	Webhook     Webhook
+}
+
+type SMTP struct {
+	Server   string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+type Webhook struct {
+	URL string
+}
+
+// Message is a summary of the result of an operation, with the issue counts (e.g. 'missing' or
+// 'failed') for each controller.
+type Message struct {
+	Operation   string                    `json:"operation"`
+	Timestamp   time.Time                 `json:"timestamp"`
+	Issues      int                       `json:"issues"`
+	Controllers map[uint32]map[string]int `json:"controllers"`
+	Errors      []string                  `json:"errors,omitempty"`
+}
+
+const LogTag = "notify"
+
+func (c Config) Enabled() bool {
+	return c.SMTP.Server != "" || c.Webhook.URL != ""
+}
+
+// Notify sends the message to the configured SMTP and webhook recipients if the number of issues
+// reaches the threshold and no notification for the same operation has been sent within the quiet
+// period.
+func Notify(c Config, message Message) error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	threshold := max(c.Threshold, 1)
+	if message.Issues < threshold {
+		debugf("%v: %v issues (below threshold %v)", message.Operation, message.Issues, threshold)
+		return nil
+	}
+
+	state := c.State
+	if state == "" {
+		state = filepath.Join(os.TempDir(), "uhppoted-app-db.notify")
+	}
+
+	sent := map[string]time.Time{}
+	if bytes, err := os.ReadFile(state); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	} else if err == nil {
+		if err := json.Unmarshal(bytes, &sent); err != nil {
+			warnf("invalid notification state file %v (%v)", state, err)
+		}
+	}
+
+	if last, ok := sent[message.Operation]; ok && c.QuietPeriod > 0 && message.Timestamp.Sub(last) < c.QuietPeriod {
+		infof("%v: notification suppressed (quiet period until %v)", message.Operation, last.Add(c.QuietPeriod).Format("2006-01-02 15:04:05"))
+		return nil
+	}
+
+	errs := []error{}
+
+	if c.SMTP.Server != "" {
+		if err := sendmail(c.SMTP, message); err != nil {
+			errs = append(errs, fmt.Errorf("SMTP: %v", err))
+		} else {
+			infof("%v: sent notification to %v", message.Operation, strings.Join(c.SMTP.To, ","))
+		}
+	}
+
+	if c.Webhook.URL != "" {
+		if err := post(c.Webhook, message); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %v", err))
+		} else {
+			infof("%v: posted notification to %v", message.Operation, c.Webhook.URL)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	sent[message.Operation] = message.Timestamp
+	if bytes, err := json.MarshalIndent(sent, "", "  "); err != nil {
+		return err
+	} else if err := os.MkdirAll(filepath.Dir(state), 0750); err != nil {
+		return err
+	} else if err := os.WriteFile(state, bytes, 0660); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m Message) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%v  %v\n", m.Operation, m.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(&b)
+
+	for _, controller := range slices.Sorted(maps.Keys(m.Controllers)) {
+		counts := []string{}
+		for _, k := range slices.Sorted(maps.Keys(m.Controllers[controller])) {
+			counts = append(counts, fmt.Sprintf("%v:%v", k, m.Controllers[controller][k]))
+		}
+
+		fmt.Fprintf(&b, "  %-10v  %v\n", controller, strings.Join(counts, "  "))
+	}
+
+	if len(m.Errors) > 0 {
+		fmt.Fprintln(&b)
+		for _, err := range m.Errors {
+			fmt.Fprintf(&b, "  ERROR  %v\n", err)
+		}
+	}
+
+	return b.String()
+}
+
+func debugf(format string, args ...any) {
+	f := fmt.Sprintf("%-10v %v", LogTag, format)
+
+	log.Debugf(f, args...)
+}
+
+func infof(format string, args ...any) {
+	f := fmt.Sprintf("%-10v %v", LogTag, format)
+
+	log.Infof(f, args...)
+}
+
+func warnf(format string, args ...any) {
+	f := fmt.Sprintf("%-10v %v", LogTag, format)
+
+	log.Warnf(f, args...)
+}
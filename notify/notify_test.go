@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func message(issues int) Message {
+	return Message{
+		Operation: "load-acl",
+		Timestamp: time.Date(2026, time.October, 19, 11, 39, 20, 0, time.UTC),
+		Issues:    issues,
+		Controllers: map[uint32]map[string]int{
+			405419896: {"failed": issues, "errors": 0},
+		},
+		Errors: []string{"DB unavailable"},
+	}
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	received := make(chan Message, 16)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m Message
+
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+		} else if body, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		} else if err := json.Unmarshal(body, &m); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			received <- m
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	defer server.Close()
+
+	config := Config{
+		State:   filepath.Join(t.TempDir(), "notify.json"),
+		Webhook: Webhook{URL: server.URL},
+	}
+
+	if err := Notify(config, message(2)); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	select {
+	case m := <-received:
+		if m.Operation != "load-acl" || m.Issues != 2 || m.Controllers[405419896]["failed"] != 2 || len(m.Errors) != 1 {
+			t.Errorf("incorrect webhook message %+v", m)
+		}
+
+	default:
+		t.Fatalf("webhook not invoked")
+	}
+}
+
+func TestNotifyThresholdAndQuietPeriod(t *testing.T) {
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+	}))
+
+	defer server.Close()
+
+	config := Config{
+		Threshold:   2,
+		QuietPeriod: time.Hour,
+		State:       filepath.Join(t.TempDir(), "notify.json"),
+		Webhook:     Webhook{URL: server.URL},
+	}
+
+	tests := []struct {
+		name     string
+		issues   int
+		offset   time.Duration
+		expected int
+	}{
+		{"below threshold", 1, 0, 0},
+		{"at threshold", 2, 0, 1},
+		{"within quiet period", 5, 30 * time.Minute, 1},
+		{"after quiet period", 5, 61 * time.Minute, 2},
+	}
+
+	for _, test := range tests {
+		m := message(test.issues)
+		m.Timestamp = m.Timestamp.Add(test.offset)
+
+		if err := Notify(config, m); err != nil {
+			t.Fatalf("%v: unexpected error (%v)", test.name, err)
+		} else if count != test.expected {
+			t.Errorf("%v: incorrect notification count - expected:%v, got:%v", test.name, test.expected, count)
+		}
+	}
+}
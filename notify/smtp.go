@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+func sendmail(c SMTP, message Message) error {
+	if len(c.To) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+
+	host, _, err := net.SplitHostPort(c.Server)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP server (%v)", err)
+	}
+
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, host)
+	}
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %v\r\n", c.From)
+	fmt.Fprintf(&b, "To: %v\r\n", strings.Join(c.To, ", "))
+	fmt.Fprintf(&b, "Subject: uhppoted-app-db %v: %v issues\r\n", message.Operation, message.Issues)
+	fmt.Fprintf(&b, "Date: %v\r\n", message.Timestamp.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Content-Type: text/plain; charset=UTF-8\r\n")
+	fmt.Fprintf(&b, "\r\n")
+	fmt.Fprintf(&b, "%v", strings.ReplaceAll(message.String(), "\n", "\r\n"))
+
+	return smtp.SendMail(c.Server, auth, c.From, c.To, b.Bytes())
+}
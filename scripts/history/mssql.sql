@@ -0,0 +1,30 @@
+-- Optional ACL change capture for SQL Server. Records the before and after image of every change to the ACL table
+-- in the ACLHistory table for the 'history-acl' command. The FOR JSON column list should be updated to match the
+-- ACL table. The PIN column is deliberately excluded so that keypad PINs are not copied to the history table.
+
+CREATE TABLE ACLHistory (
+    Timestamp  DATETIME      DEFAULT GETUTCDATE(),
+    Operation  VARCHAR(16)   DEFAULT '',
+    CardNumber INT           DEFAULT 0,
+    UserName   VARCHAR(128)  DEFAULT '',
+    OldRow     NVARCHAR(MAX) NULL,
+    NewRow     NVARCHAR(MAX) NULL
+);
+GO
+
+CREATE TRIGGER ACLHistoryTrigger ON ACL AFTER INSERT, UPDATE, DELETE AS
+BEGIN
+    SET NOCOUNT ON;
+
+    INSERT INTO ACLHistory (Operation, CardNumber, UserName, OldRow, NewRow)
+    SELECT CASE WHEN d.CardNumber IS NULL THEN 'INSERT' WHEN i.CardNumber IS NULL THEN 'DELETE' ELSE 'UPDATE' END,
+           COALESCE(i.CardNumber, d.CardNumber),
+           SUSER_SNAME(),
+           CASE WHEN d.CardNumber IS NULL THEN NULL ELSE (SELECT d.Name, d.CardNumber, d.StartDate, d.EndDate, d.GreatHall, d.Gryffindor, d.HufflePuff, d.Ravenclaw, d.Slytherin, d.Kitchen, d.Dungeon, d.Hogsmeade FOR JSON PATH, WITHOUT_ARRAY_WRAPPER, INCLUDE_NULL_VALUES) END,
+           CASE WHEN i.CardNumber IS NULL THEN NULL ELSE (SELECT i.Name, i.CardNumber, i.StartDate, i.EndDate, i.GreatHall, i.Gryffindor, i.HufflePuff, i.Ravenclaw, i.Slytherin, i.Kitchen, i.Dungeon, i.Hogsmeade FOR JSON PATH, WITHOUT_ARRAY_WRAPPER, INCLUDE_NULL_VALUES) END
+    FROM inserted i FULL OUTER JOIN deleted d ON i.CardNumber = d.CardNumber;
+END;
+GO
+
+GRANT SELECT ON ACLHistory TO uhppoted;
+GO
@@ -0,0 +1,28 @@
+-- Optional ACL change capture for MySQL. Records the before and after image of every change to the ACL table
+-- in the ACLHistory table for the 'history-acl' command. The JSON_OBJECT column list should be updated to match
+-- the ACL table. The PIN column is deliberately excluded so that keypad PINs are not copied to the history table.
+
+USE uhppoted;
+
+CREATE TABLE ACLHistory (
+    Timestamp  DATETIME     DEFAULT CURRENT_TIMESTAMP,
+    Operation  VARCHAR(16)  DEFAULT '',
+    CardNumber INT          DEFAULT 0,
+    UserName   VARCHAR(128) DEFAULT '',
+    OldRow     TEXT         NULL,
+    NewRow     TEXT         NULL
+);
+
+CREATE TRIGGER ACLInsert AFTER INSERT ON ACL FOR EACH ROW
+    INSERT INTO ACLHistory (Operation, CardNumber, UserName, NewRow)
+           VALUES ('INSERT', NEW.CardNumber, USER(), JSON_OBJECT('Name',NEW.Name,'CardNumber',NEW.CardNumber,'StartDate',NEW.StartDate,'EndDate',NEW.EndDate,'GreatHall',NEW.GreatHall,'Gryffindor',NEW.Gryffindor,'HufflePuff',NEW.HufflePuff,'Ravenclaw',NEW.Ravenclaw,'Slytherin',NEW.Slytherin,'Kitchen',NEW.Kitchen,'Dungeon',NEW.Dungeon,'Hogsmeade',NEW.Hogsmeade));
+
+CREATE TRIGGER ACLUpdate AFTER UPDATE ON ACL FOR EACH ROW
+    INSERT INTO ACLHistory (Operation, CardNumber, UserName, OldRow, NewRow)
+           VALUES ('UPDATE', NEW.CardNumber, USER(), JSON_OBJECT('Name',OLD.Name,'CardNumber',OLD.CardNumber,'StartDate',OLD.StartDate,'EndDate',OLD.EndDate,'GreatHall',OLD.GreatHall,'Gryffindor',OLD.Gryffindor,'HufflePuff',OLD.HufflePuff,'Ravenclaw',OLD.Ravenclaw,'Slytherin',OLD.Slytherin,'Kitchen',OLD.Kitchen,'Dungeon',OLD.Dungeon,'Hogsmeade',OLD.Hogsmeade), JSON_OBJECT('Name',NEW.Name,'CardNumber',NEW.CardNumber,'StartDate',NEW.StartDate,'EndDate',NEW.EndDate,'GreatHall',NEW.GreatHall,'Gryffindor',NEW.Gryffindor,'HufflePuff',NEW.HufflePuff,'Ravenclaw',NEW.Ravenclaw,'Slytherin',NEW.Slytherin,'Kitchen',NEW.Kitchen,'Dungeon',NEW.Dungeon,'Hogsmeade',NEW.Hogsmeade));
+
+CREATE TRIGGER ACLDelete AFTER DELETE ON ACL FOR EACH ROW
+    INSERT INTO ACLHistory (Operation, CardNumber, UserName, OldRow)
+           VALUES ('DELETE', OLD.CardNumber, USER(), JSON_OBJECT('Name',OLD.Name,'CardNumber',OLD.CardNumber,'StartDate',OLD.StartDate,'EndDate',OLD.EndDate,'GreatHall',OLD.GreatHall,'Gryffindor',OLD.Gryffindor,'HufflePuff',OLD.HufflePuff,'Ravenclaw',OLD.Ravenclaw,'Slytherin',OLD.Slytherin,'Kitchen',OLD.Kitchen,'Dungeon',OLD.Dungeon,'Hogsmeade',OLD.Hogsmeade));
+
+GRANT SELECT ON uhppoted.ACLHistory TO uhppoted;
@@ -0,0 +1,34 @@
+-- Optional ACL change capture for PostgreSQL. Records the before and after image of every change to the ACL table
+-- in the ACLHistory table for the 'history-acl' command. The PIN column is deliberately removed from the row images
+-- so that keypad PINs are not copied to the history table.
+
+CREATE TABLE ACLHistory (
+    Timestamp  TIMESTAMP    DEFAULT CURRENT_TIMESTAMP,
+    Operation  VARCHAR(16)  DEFAULT '',
+    CardNumber INT          DEFAULT 0,
+    UserName   VARCHAR(128) DEFAULT '',
+    OldRow     TEXT         NULL,
+    NewRow     TEXT         NULL
+);
+
+CREATE FUNCTION acl_history() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO ACLHistory (Operation, CardNumber, UserName, NewRow)
+               VALUES (TG_OP, NEW.CardNumber, current_user, (row_to_json(NEW)::jsonb - 'pin')::text);
+    ELSIF TG_OP = 'UPDATE' THEN
+        INSERT INTO ACLHistory (Operation, CardNumber, UserName, OldRow, NewRow)
+               VALUES (TG_OP, NEW.CardNumber, current_user, (row_to_json(OLD)::jsonb - 'pin')::text, (row_to_json(NEW)::jsonb - 'pin')::text);
+    ELSIF TG_OP = 'DELETE' THEN
+        INSERT INTO ACLHistory (Operation, CardNumber, UserName, OldRow)
+               VALUES (TG_OP, OLD.CardNumber, current_user, (row_to_json(OLD)::jsonb - 'pin')::text);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ACLHistoryTrigger AFTER INSERT OR UPDATE OR DELETE ON ACL
+    FOR EACH ROW EXECUTE FUNCTION acl_history();
+
+GRANT SELECT,INSERT ON ACLHistory TO uhppoted;
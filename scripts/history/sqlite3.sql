@@ -0,0 +1,30 @@
+-- Optional ACL change capture for sqlite3. Records the before and after image of every change to the ACL table
+-- in the ACLHistory table for the 'history-acl' command. The json_object column list should be updated to match
+-- the ACL table. The PIN column is deliberately excluded so that keypad PINs are not copied to the history table.
+
+CREATE TABLE ACLHistory (
+    Timestamp  DATETIME DEFAULT (datetime(CURRENT_TIMESTAMP, 'localtime')),
+    Operation  TEXT     DEFAULT '',
+    CardNumber INTEGER  DEFAULT 0,
+    UserName   TEXT     DEFAULT '',
+    OldRow     TEXT     NULL,
+    NewRow     TEXT     NULL
+);
+
+CREATE TRIGGER ACLInsert AFTER INSERT ON ACL
+BEGIN
+    INSERT INTO ACLHistory (Operation, CardNumber, NewRow)
+           VALUES ('INSERT', NEW.CardNumber, json_object('Name',NEW.Name,'CardNumber',NEW.CardNumber,'StartDate',NEW.StartDate,'EndDate',NEW.EndDate,'GreatHall',NEW.GreatHall,'Gryffindor',NEW.Gryffindor,'HufflePuff',NEW.HufflePuff,'Ravenclaw',NEW.Ravenclaw,'Slytherin',NEW.Slytherin,'Kitchen',NEW.Kitchen,'Dungeon',NEW.Dungeon,'Hogsmeade',NEW.Hogsmeade));
+END;
+
+CREATE TRIGGER ACLUpdate AFTER UPDATE ON ACL
+BEGIN
+    INSERT INTO ACLHistory (Operation, CardNumber, OldRow, NewRow)
+           VALUES ('UPDATE', NEW.CardNumber, json_object('Name',OLD.Name,'CardNumber',OLD.CardNumber,'StartDate',OLD.StartDate,'EndDate',OLD.EndDate,'GreatHall',OLD.GreatHall,'Gryffindor',OLD.Gryffindor,'HufflePuff',OLD.HufflePuff,'Ravenclaw',OLD.Ravenclaw,'Slytherin',OLD.Slytherin,'Kitchen',OLD.Kitchen,'Dungeon',OLD.Dungeon,'Hogsmeade',OLD.Hogsmeade), json_object('Name',NEW.Name,'CardNumber',NEW.CardNumber,'StartDate',NEW.StartDate,'EndDate',NEW.EndDate,'GreatHall',NEW.GreatHall,'Gryffindor',NEW.Gryffindor,'HufflePuff',NEW.HufflePuff,'Ravenclaw',NEW.Ravenclaw,'Slytherin',NEW.Slytherin,'Kitchen',NEW.Kitchen,'Dungeon',NEW.Dungeon,'Hogsmeade',NEW.Hogsmeade));
+END;
+
+CREATE TRIGGER ACLDelete AFTER DELETE ON ACL
+BEGIN
+    INSERT INTO ACLHistory (Operation, CardNumber, OldRow)
+           VALUES ('DELETE', OLD.CardNumber, json_object('Name',OLD.Name,'CardNumber',OLD.CardNumber,'StartDate',OLD.StartDate,'EndDate',OLD.EndDate,'GreatHall',OLD.GreatHall,'Gryffindor',OLD.Gryffindor,'HufflePuff',OLD.HufflePuff,'Ravenclaw',OLD.Ravenclaw,'Slytherin',OLD.Slytherin,'Kitchen',OLD.Kitchen,'Dungeon',OLD.Dungeon,'Hogsmeade',OLD.Hogsmeade));
+END;
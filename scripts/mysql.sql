@@ -36,14 +36,74 @@ CREATE TABLE Audit (
     Controller INT          DEFAULT 0,
     CardNumber INT          DEFAULT 0,
     Status     VARCHAR(64)  DEFAULT '',
-    Card       VARCHAR(255) DEFAULT ''
+    Card       VARCHAR(255) DEFAULT '',
+    RunID      VARCHAR(32)  DEFAULT ''
 );
 
 CREATE TABLE OperationsLog (
     Timestamp  DATETIME     DEFAULT CURRENT_TIMESTAMP,
     Operation  VARCHAR(64)  DEFAULT '',
     Controller INT          NULL,
-    Detail     VARCHAR(255) DEFAULT ''
+    Detail     VARCHAR(255) DEFAULT '',
+    RunID      VARCHAR(32)  DEFAULT '',
+    Counters   VARCHAR(255) DEFAULT NULL,
+    Unchanged  INT          NULL,
+    Updated    INT          NULL,
+    Added      INT          NULL,
+    Deleted    INT          NULL,
+    Failed     INT          NULL,
+    Errors     INT          NULL,
+    Records    INT          NULL,
+    Incorrect  INT          NULL,
+    Missing    INT          NULL,
+    Extra      INT          NULL,
+    Fixed      INT          NULL,
+    duration_ms INT         NULL
+);
+
+CREATE TABLE AccessGroups (
+    Name       VARCHAR(256) UNIQUE,
+    GreatHall  TINYINT DEFAULT 0,
+    Gryffindor TINYINT DEFAULT 0,
+    HufflePuff TINYINT DEFAULT 0,
+    Ravenclaw  TINYINT DEFAULT 0,
+    Slytherin  TINYINT DEFAULT 0,
+    Kitchen    TINYINT DEFAULT 0,
+    Dungeon    TINYINT DEFAULT 0,
+    Hogsmeade  TINYINT DEFAULT 0
+);
+
+CREATE TABLE GroupMembers (
+    CardNumber INT          NOT NULL,
+    GroupName  VARCHAR(256) NOT NULL,
+    StartDate  DATE         NULL,
+    EndDate    DATE         NULL
+);
+
+CREATE TABLE TimeProfiles (
+    ProfileID       INT         NOT NULL UNIQUE,
+    StartDate       DATE        NOT NULL,
+    EndDate         DATE        NOT NULL,
+    Monday          INT         DEFAULT 0,
+    Tuesday         INT         DEFAULT 0,
+    Wednesday       INT         DEFAULT 0,
+    Thursday        INT         DEFAULT 0,
+    Friday          INT         DEFAULT 0,
+    Saturday        INT         DEFAULT 0,
+    Sunday          INT         DEFAULT 0,
+    Segment1Start   VARCHAR(5)  NULL,
+    Segment1End     VARCHAR(5)  NULL,
+    Segment2Start   VARCHAR(5)  NULL,
+    Segment2End     VARCHAR(5)  NULL,
+    Segment3Start   VARCHAR(5)  NULL,
+    Segment3End     VARCHAR(5)  NULL,
+    LinkedProfileID INT         DEFAULT 0
+);
+
+CREATE TABLE Holidays (
+    Date       DATE         NOT NULL,
+    Name       VARCHAR(256) DEFAULT '',
+    Controller INT          DEFAULT 0
 );
 
 CREATE USER uhppoted IDENTIFIED BY 'qwerty';
@@ -52,6 +112,9 @@ GRANT SELECT,INSERT,UPDATE,DELETE ON uhppoted.ACL           TO uhppoted;
 GRANT SELECT,INSERT,UPDATE,DELETE ON uhppoted.Events        TO uhppoted;
 GRANT SELECT,INSERT,UPDATE,DELETE ON uhppoted.Audit         TO uhppoted;
 GRANT SELECT,INSERT,UPDATE,DELETE ON uhppoted.OperationsLog TO uhppoted;
+GRANT SELECT                      ON uhppoted.AccessGroups  TO uhppoted;
+GRANT SELECT                      ON uhppoted.GroupMembers  TO uhppoted;
+GRANT SELECT                      ON uhppoted.TimeProfiles  TO uhppoted;
 
 INSERT INTO ACL    (Name, CardNumber,PIN,StartDate,EndDate,GreatHall,Gryffindor,HufflePuff,Ravenclaw,Slytherin,Kitchen,Dungeon,Hogsmeade)
             VALUES ('Albus Dumbledore', 10058400, 0, '2023-01-01', '2023-12-31', 1,1,1,1,1,1,1,1);
@@ -73,3 +136,6 @@ INSERT INTO ACL    (Name, CardNumber,PIN,StartDate,EndDate,GreatHall,Gryffindor,
 
 INSERT INTO ACL    (Name, CardNumber,PIN,StartDate,EndDate,GreatHall,Gryffindor,HufflePuff,Ravenclaw,Slytherin,Kitchen,Dungeon,Hogsmeade)
             VALUES ('Tom Riddle', 10058406, 0, '2023-01-01', '2023-12-31', 0,0,0,0,0,0,1,1);
+
+INSERT INTO TimeProfiles (ProfileID,StartDate,EndDate,Monday,Tuesday,Wednesday,Thursday,Friday,Saturday,Sunday,Segment1Start,Segment1End,Segment2Start,Segment2End,Segment3Start,Segment3End,LinkedProfileID)
+            VALUES (29, '2023-01-01', '2023-12-31', 0,0,0,0,0,1,1, '09:00', '17:00', '', '', '', '', 0);
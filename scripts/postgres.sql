@@ -36,14 +36,74 @@ CREATE TABLE Audit (
     Controller INT          DEFAULT 0,
     CardNumber INT          DEFAULT 0,
     Status     VARCHAR(64)  DEFAULT '',
-    Card       VARCHAR(255) DEFAULT ''
+    Card       VARCHAR(255) DEFAULT '',
+    RunID      VARCHAR(32)  DEFAULT ''
 );
 
 CREATE TABLE OperationsLog (
     Timestamp  TIMESTAMP    DEFAULT CURRENT_TIMESTAMP,
     Operation  VARCHAR(64)  DEFAULT '',
     Controller INT          NULL,
-    Detail     VARCHAR(255) DEFAULT ''
+    Detail     VARCHAR(255) DEFAULT '',
+    RunID      VARCHAR(32)  DEFAULT '',
+    Counters   VARCHAR(255) DEFAULT NULL,
+    Unchanged  INT          NULL,
+    Updated    INT          NULL,
+    Added      INT          NULL,
+    Deleted    INT          NULL,
+    Failed     INT          NULL,
+    Errors     INT          NULL,
+    Records    INT          NULL,
+    Incorrect  INT          NULL,
+    Missing    INT          NULL,
+    Extra      INT          NULL,
+    Fixed      INT          NULL,
+    duration_ms INT         NULL
+);
+
+CREATE TABLE AccessGroups (
+    Name       VARCHAR(256) UNIQUE,
+    GreatHall  SMALLINT DEFAULT 0,
+    Gryffindor SMALLINT DEFAULT 0,
+    HufflePuff SMALLINT DEFAULT 0,
+    Ravenclaw  SMALLINT DEFAULT 0,
+    Slytherin  SMALLINT DEFAULT 0,
+    Kitchen    SMALLINT DEFAULT 0,
+    Dungeon    SMALLINT DEFAULT 0,
+    Hogsmeade  SMALLINT DEFAULT 0
+);
+
+CREATE TABLE GroupMembers (
+    CardNumber INT          NOT NULL,
+    GroupName  VARCHAR(256) NOT NULL,
+    StartDate  DATE         NULL,
+    EndDate    DATE         NULL
+);
+
+CREATE TABLE TimeProfiles (
+    ProfileID       INT         NOT NULL UNIQUE,
+    StartDate       DATE        NOT NULL,
+    EndDate         DATE        NOT NULL,
+    Monday          INT         DEFAULT 0,
+    Tuesday         INT         DEFAULT 0,
+    Wednesday       INT         DEFAULT 0,
+    Thursday        INT         DEFAULT 0,
+    Friday          INT         DEFAULT 0,
+    Saturday        INT         DEFAULT 0,
+    Sunday          INT         DEFAULT 0,
+    Segment1Start   VARCHAR(5)  NULL,
+    Segment1End     VARCHAR(5)  NULL,
+    Segment2Start   VARCHAR(5)  NULL,
+    Segment2End     VARCHAR(5)  NULL,
+    Segment3Start   VARCHAR(5)  NULL,
+    Segment3End     VARCHAR(5)  NULL,
+    LinkedProfileID INT         DEFAULT 0
+);
+
+CREATE TABLE Holidays (
+    Date       DATE         NOT NULL,
+    Name       VARCHAR(256) DEFAULT '',
+    Controller INT          DEFAULT 0
 );
 
 
@@ -53,6 +113,9 @@ GRANT SELECT,INSERT,UPDATE,DELETE ON ACL           TO uhppoted;
 GRANT SELECT,INSERT,UPDATE,DELETE ON Events        TO uhppoted;
 GRANT SELECT,INSERT,UPDATE,DELETE ON Audit         TO uhppoted;
 GRANT SELECT,INSERT,UPDATE,DELETE ON OperationsLog TO uhppoted;
+GRANT SELECT                      ON AccessGroups  TO uhppoted;
+GRANT SELECT                      ON GroupMembers  TO uhppoted;
+GRANT SELECT                      ON TimeProfiles  TO uhppoted;
 
 INSERT INTO ACL    (Name, CardNumber,PIN,StartDate,EndDate,GreatHall,Gryffindor,HufflePuff,Ravenclaw,Slytherin,Kitchen,Dungeon,Hogsmeade)
             VALUES ('Albus Dumbledore', 10058400, 0, '2023-01-01', '2023-12-31', 1,1,1,1,1,1,1,1);
@@ -74,3 +137,6 @@ INSERT INTO ACL    (Name, CardNumber,PIN,StartDate,EndDate,GreatHall,Gryffindor,
 
 INSERT INTO ACL    (Name, CardNumber,PIN,StartDate,EndDate,GreatHall,Gryffindor,HufflePuff,Ravenclaw,Slytherin,Kitchen,Dungeon,Hogsmeade)
             VALUES ('Tom Riddle', 10058406, 0, '2023-01-01', '2023-12-31', 0,0,0,0,0,0,1,1);
+
+INSERT INTO TimeProfiles (ProfileID,StartDate,EndDate,Monday,Tuesday,Wednesday,Thursday,Friday,Saturday,Sunday,Segment1Start,Segment1End,Segment2Start,Segment2End,Segment3Start,Segment3End,LinkedProfileID)
+            VALUES (29, '2023-01-01', '2023-12-31', 0,0,0,0,0,1,1, '09:00', '17:00', '', '', '', '', 0);
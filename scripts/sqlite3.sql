@@ -33,14 +33,74 @@ CREATE TABLE Audit (
     Controller INTEGER  DEFAULT 0,
     CardNumber INTEGER  DEFAULT 0,
     Status     TEXT     DEFAULT '',
-    Card       TEXT     DEFAULT ''
+    Card       TEXT     DEFAULT '',
+    RunID      TEXT     DEFAULT ''
 );
 
 CREATE TABLE OperationsLog (
     Timestamp  DATETIME DEFAULT (datetime(CURRENT_TIMESTAMP, 'localtime')),
     Operation  TEXT     DEFAULT '',
     Controller INTEGER  NULL,
-    Detail     TEXT     DEFAULT ''
+    Detail     TEXT     DEFAULT '',
+    RunID      TEXT     DEFAULT '',
+    Counters   TEXT     NULL,
+    Unchanged  INTEGER  NULL,
+    Updated    INTEGER  NULL,
+    Added      INTEGER  NULL,
+    Deleted    INTEGER  NULL,
+    Failed     INTEGER  NULL,
+    Errors     INTEGER  NULL,
+    Records    INTEGER  NULL,
+    Incorrect  INTEGER  NULL,
+    Missing    INTEGER  NULL,
+    Extra      INTEGER  NULL,
+    Fixed      INTEGER  NULL,
+    duration_ms INTEGER NULL
+);
+
+CREATE TABLE AccessGroups (
+    Name       TEXT    UNIQUE,
+    GreatHall  INTEGER DEFAULT 0,
+    Gryffindor INTEGER DEFAULT 0,
+    HufflePuff INTEGER DEFAULT 0,
+    Ravenclaw  INTEGER DEFAULT 0,
+    Slytherin  INTEGER DEFAULT 0,
+    Kitchen    INTEGER DEFAULT 0,
+    Dungeon    INTEGER DEFAULT 0,
+    Hogsmeade  INTEGER DEFAULT 0
+);
+
+CREATE TABLE GroupMembers (
+    CardNumber INTEGER NOT NULL,
+    GroupName  TEXT    NOT NULL,
+    StartDate  TEXT    DEFAULT '',
+    EndDate    TEXT    DEFAULT ''
+);
+
+CREATE TABLE TimeProfiles (
+    ProfileID       INTEGER NOT NULL UNIQUE,
+    StartDate       TEXT    NOT NULL,
+    EndDate         TEXT    NOT NULL,
+    Monday          INTEGER DEFAULT 0,
+    Tuesday         INTEGER DEFAULT 0,
+    Wednesday       INTEGER DEFAULT 0,
+    Thursday        INTEGER DEFAULT 0,
+    Friday          INTEGER DEFAULT 0,
+    Saturday        INTEGER DEFAULT 0,
+    Sunday          INTEGER DEFAULT 0,
+    Segment1Start   TEXT    DEFAULT '',
+    Segment1End     TEXT    DEFAULT '',
+    Segment2Start   TEXT    DEFAULT '',
+    Segment2End     TEXT    DEFAULT '',
+    Segment3Start   TEXT    DEFAULT '',
+    Segment3End     TEXT    DEFAULT '',
+    LinkedProfileID INTEGER DEFAULT 0
+);
+
+CREATE TABLE Holidays (
+    Date       TEXT    NOT NULL,
+    Name       TEXT    DEFAULT '',
+    Controller INTEGER DEFAULT 0
 );
 
 INSERT INTO ACL    (Name, CardNumber,PIN,StartDate,EndDate,GreatHall,Gryffindor,HufflePuff,Ravenclaw,Slytherin,Kitchen,Dungeon,Hogsmeade)
@@ -63,3 +123,6 @@ INSERT INTO ACL    (Name, CardNumber,PIN,StartDate,EndDate,GreatHall,Gryffindor,
 
 INSERT INTO ACL    (Name, CardNumber,PIN,StartDate,EndDate,GreatHall,Gryffindor,HufflePuff,Ravenclaw,Slytherin,Kitchen,Dungeon,Hogsmeade)
             VALUES ('Tom Riddle', 10058406, 0, '2023-01-01', '2023-12-31', 0,0,0,0,0,0,1,1);
+
+INSERT INTO TimeProfiles (ProfileID,StartDate,EndDate,Monday,Tuesday,Wednesday,Thursday,Friday,Saturday,Sunday,Segment1Start,Segment1End,Segment2Start,Segment2End,Segment3Start,Segment3End,LinkedProfileID)
+            VALUES (29, '2023-01-01', '2023-12-31', 0,0,0,0,0,1,1, '09:00', '17:00', '', '', '', '', 0);